@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	flags "github.com/jessevdk/go-flags"
+	"github.com/viant/afs"
+	"github.com/viant/afs/url"
+
+	"github.com/viant/forge/backend/service/meta"
+)
+
+type Options struct {
+	Root         string   `short:"r" long:"root" required:"true" description:"metadata root containing the window folder (local path or afs URL)"`
+	Window       string   `short:"w" long:"window" required:"true" description:"window key, e.g. order or order/detail"`
+	Left         string   `short:"a" long:"left" default:"web/desktop" description:"left target as platform[/formFactor[/surface]]"`
+	Right        string   `short:"b" long:"right" required:"true" description:"right target as platform[/formFactor[/surface]]"`
+	Capabilities []string `short:"c" long:"capability" description:"client capability of both targets (repeatable)"`
+	Format       string   `short:"f" long:"format" choice:"text" choice:"json" default:"text" description:"output format"`
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout))
+}
+
+// run diffs the window described by args and returns the exit code: 0 when
+// both targets resolve alike, 1 when they differ and 2 on errors.
+func run(args []string, stdout io.Writer) int {
+	opts := Options{}
+	if _, err := flags.NewParser(&opts, flags.Default).ParseArgs(args); err != nil {
+		if ferr, ok := err.(*flags.Error); ok && ferr.Type == flags.ErrHelp {
+			return 0
+		}
+		return 2
+	}
+
+	var targets []*meta.TargetContext
+	for _, value := range []string{opts.Left, opts.Right} {
+		target, err := meta.ParseTarget(value)
+		if err != nil {
+			log.Printf("error: %v", err)
+			return 2
+		}
+		target.Capabilities = opts.Capabilities
+		targets = append(targets, target)
+	}
+
+	loader := meta.New(afs.New(), url.Join(opts.Root, "window"))
+	diff, err := loader.DiffWindow(context.Background(), opts.Window, targets[0], targets[1])
+	if err != nil {
+		log.Printf("error: %v", err)
+		return 2
+	}
+
+	if opts.Format == "json" {
+		encoder := json.NewEncoder(stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(diff); err != nil {
+			log.Printf("error: %v", err)
+			return 2
+		}
+	} else {
+		fmt.Fprintf(stdout, "--- %s (%s)\n+++ %s (%s)\n", diff.Left, relative(opts.Root, diff.LeftURL), diff.Right, relative(opts.Root, diff.RightURL))
+		for _, change := range diff.Changes {
+			switch change.Kind {
+			case meta.DiffAdded:
+				fmt.Fprintf(stdout, "+ %s: %s (%s)\n", change.YAMLPath, value(change.Right), relative(opts.Root, change.RightSource))
+			case meta.DiffRemoved:
+				fmt.Fprintf(stdout, "- %s: %s (%s)\n", change.YAMLPath, value(change.Left), relative(opts.Root, change.LeftSource))
+			default:
+				fmt.Fprintf(stdout, "~ %s: %s -> %s (%s | %s)\n", change.YAMLPath, value(change.Left), value(change.Right),
+					relative(opts.Root, change.LeftSource), relative(opts.Root, change.RightSource))
+			}
+		}
+		fmt.Fprintf(stdout, "%d changes\n", len(diff.Changes))
+	}
+
+	if len(diff.Changes) > 0 {
+		return 1
+	}
+	return 0
+}
+
+// relative trims the metadata root from a source URL.
+func relative(root, source string) string {
+	root = strings.TrimSuffix(strings.TrimPrefix(root, "file://"), "/")
+	if index := strings.Index(source, root+"/"); root != "" && index != -1 {
+		return source[index+len(root)+1:]
+	}
+	return source
+}
+
+func value(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
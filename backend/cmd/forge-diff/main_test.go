@@ -0,0 +1,35 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRun_ResolvesWindowsUnderRootWindowFolder(t *testing.T) {
+	root := t.TempDir()
+	mustWriteDiffFile(t, filepath.Join(root, "window", "order", "main.yaml"), "namespace: order\nview:\n  content:\n    title: Orders\n")
+	mustWriteDiffFile(t, filepath.Join(root, "window", "order", "ios", "phone", "main.yaml"), "namespace: order\nview:\n  content:\n    title: My orders\n")
+
+	stdout := &bytes.Buffer{}
+	code := run([]string{"--root", root, "--window", "order", "--right", "ios/phone"}, stdout)
+	if code != 1 {
+		t.Fatalf("expected exit code 1 for differing targets, got %d: %s", code, stdout.String())
+	}
+	output := stdout.String()
+	if !strings.Contains(output, `~ $.view.content.title: "Orders" -> "My orders"`) || !strings.Contains(output, "window/order/ios/phone/main.yaml") {
+		t.Fatalf("unexpected diff output:\n%s", output)
+	}
+}
+
+func mustWriteDiffFile(t *testing.T, path string, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("mkdir %s: %v", path, err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("write %s: %v", path, err)
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	flags "github.com/jessevdk/go-flags"
+	"github.com/viant/afs"
+
+	"github.com/viant/forge/backend/service/lint"
+	"github.com/viant/forge/backend/service/meta"
+)
+
+type Options struct {
+	Root              string   `short:"r" long:"root" required:"true" description:"metadata root containing the window folder (local path or afs URL)"`
+	Targets           []string `short:"t" long:"target" description:"target as platform[/formFactor[/surface]] (repeatable; default: web/desktop, android/phone, android/tablet, ios/phone, ios/tablet)"`
+	HandlerNamespaces []string `long:"handler-ns" description:"handler namespace registered by the host application (repeatable)"`
+	Format            string   `short:"f" long:"format" choice:"text" choice:"json" default:"text" description:"output format"`
+	Strict            bool     `long:"strict" description:"treat warnings as errors for the exit code"`
+}
+
+func main() {
+	opts := Options{}
+	if _, err := flags.NewParser(&opts, flags.Default).Parse(); err != nil {
+		if ferr, ok := err.(*flags.Error); ok && ferr.Type == flags.ErrHelp {
+			os.Exit(0)
+		}
+		os.Exit(2)
+	}
+
+	var targets []*meta.TargetContext
+	for _, value := range opts.Targets {
+		target, err := lint.ParseTarget(value)
+		if err != nil {
+			log.Printf("error: %v", err)
+			os.Exit(2)
+		}
+		targets = append(targets, target)
+	}
+
+	linter := lint.New(afs.New(), opts.Root,
+		lint.WithTargets(targets...),
+		lint.WithValidateOptions(meta.WithHandlerNamespaces(opts.HandlerNamespaces...)),
+	)
+	report, err := linter.Run(context.Background())
+	if err != nil {
+		log.Printf("error: %v", err)
+		os.Exit(2)
+	}
+
+	if opts.Format == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			log.Printf("error: %v", err)
+			os.Exit(2)
+		}
+	} else {
+		for _, finding := range report.Findings {
+			fmt.Printf("%s: %s [%s] (%s)\n", finding.Severity, finding.Error(), finding.Code, strings.Join(finding.Targets, ", "))
+		}
+		fmt.Printf("%d windows, %d findings\n", len(report.Windows), len(report.Findings))
+	}
+
+	if report.HasErrors() || (opts.Strict && len(report.Findings) > 0) {
+		os.Exit(1)
+	}
+}
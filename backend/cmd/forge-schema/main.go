@@ -0,0 +1,58 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+
+	flags "github.com/jessevdk/go-flags"
+
+	"github.com/viant/forge/backend/service/schema"
+)
+
+type Options struct {
+	Document  string `short:"d" long:"document" choice:"window" choice:"navigation" default:"window" description:"metadata document to describe"`
+	Output    string `short:"o" long:"output" description:"output file (default: stdout)"`
+	ID        string `long:"id" description:"$id of the schema document"`
+	NoImports bool   `long:"no-imports" description:"do not accept $import directives in place of objects and lists"`
+}
+
+func main() {
+	opts := Options{}
+	if _, err := flags.NewParser(&opts, flags.Default).Parse(); err != nil {
+		if ferr, ok := err.(*flags.Error); ok && ferr.Type == flags.ErrHelp {
+			os.Exit(0)
+		}
+		os.Exit(2)
+	}
+
+	options := []schema.Option{schema.WithID(opts.ID)}
+	if opts.NoImports {
+		options = append(options, schema.WithoutImports())
+	}
+	generate := schema.Window
+	if opts.Document == "navigation" {
+		generate = schema.Navigation
+	}
+	document, err := generate(options...)
+	if err != nil {
+		log.Printf("error: %v", err)
+		os.Exit(2)
+	}
+
+	output := os.Stdout
+	if opts.Output != "" {
+		if output, err = os.Create(opts.Output); err != nil {
+			log.Printf("error: %v", err)
+			os.Exit(2)
+		}
+		defer output.Close()
+	}
+	encoder := json.NewEncoder(output)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(document); err != nil {
+		log.Printf("error: %v", err)
+		os.Exit(2)
+	}
+}
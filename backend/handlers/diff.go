@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/viant/afs/url"
+	"github.com/viant/forge/backend/service/meta"
+)
+
+type WindowDiffResponse struct {
+	Status string           `json:"status"`
+	Data   *meta.WindowDiff `json:"data"`
+}
+
+// WindowDiffHandler compares the window at the path below baseURI for the
+// left and right targets ("platform[/formFactor[/surface]]"), e.g.
+// GET /meta/diff/order?left=ios/tablet&right=web/desktop. The other target
+// parameters, such as capabilities or locale, apply to both sides.
+func WindowDiffHandler(loader *meta.Service, baseURL string, baseURI string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := strings.Trim(strings.TrimPrefix(r.URL.Path, baseURI), "/")
+		if key == "" {
+			http.Error(w, "missing path in URL", http.StatusBadRequest)
+			return
+		}
+		shared := targetContextFromRequest(r)
+		var sides [2]*meta.TargetContext
+		for i, name := range []string{"left", "right"} {
+			side, err := meta.ParseTarget(r.URL.Query().Get(name))
+			if err != nil {
+				writeProblem(w, &Problem{Type: "about:blank", Title: "Invalid diff request", Status: http.StatusBadRequest, Detail: err.Error(), Instance: r.URL.Path})
+				return
+			}
+			target := *shared
+			target.Platform, target.FormFactor, target.Surface = side.Platform, side.FormFactor, side.Surface
+			sides[i] = &target
+		}
+		diff, err := loader.DiffWindow(r.Context(), url.Join(baseURL, key), sides[0], sides[1])
+		if err != nil {
+			writeLoadProblem(w, r, err)
+			return
+		}
+		diff.Key = key
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(WindowDiffResponse{Status: "ok", Data: diff})
+	}
+}
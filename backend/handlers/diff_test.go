@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/viant/afs"
+	"github.com/viant/forge/backend/service/meta"
+)
+
+func TestWindowDiffHandler_ComparesTargets(t *testing.T) {
+	root := t.TempDir()
+	base := filepath.Join(root, "window")
+	mustWriteHandlerMetaFile(t, filepath.Join(base, "order", "main.yaml"), "namespace: Order\nregion: main\n")
+	mustWriteHandlerMetaFile(t, filepath.Join(base, "order", "android", "main.yaml"), "namespace: Order\nregion: drawer\n")
+	baseURL := "file://" + filepath.ToSlash(base)
+	handler := WindowDiffHandler(meta.New(afs.New(), baseURL), baseURL, "/v1/api/diff/")
+
+	recorder := httptest.NewRecorder()
+	handler(recorder, httptest.NewRequest(http.MethodGet, "/v1/api/diff/order?left=web&right=android/phone", nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	var response WindowDiffResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if response.Data.Key != "order" || len(response.Data.Changes) != 1 || response.Data.Changes[0].YAMLPath != "$.region" {
+		t.Fatalf("unexpected diff %+v", response.Data)
+	}
+
+	recorder = httptest.NewRecorder()
+	handler(recorder, httptest.NewRequest(http.MethodGet, "/v1/api/diff/order?left=web", nil))
+	if recorder.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 without right target, got %d", recorder.Code)
+	}
+}
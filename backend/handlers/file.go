@@ -3,7 +3,10 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"github.com/viant/forge/backend/service/file"
+	"io"
 	"log"
 	"mime"
 	"net/http"
@@ -50,7 +53,10 @@ func (h *FileHandler) ListHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// DownloadHandler handles the `/download` endpoint.
+// DownloadHandler handles the `/download` endpoint. The file is streamed
+// rather than loaded into memory, with Content-Length, Last-Modified, ETag,
+// conditional GET and Range/If-Range support; disposition=inline serves it
+// for in-browser previews instead of as an attachment.
 func (h *FileHandler) DownloadHandler(w http.ResponseWriter, r *http.Request) {
 	URI := r.URL.Query().Get("uri")
 	if URI == "" {
@@ -64,7 +70,7 @@ func (h *FileHandler) DownloadHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	ctx := context.Background()
+	ctx := r.Context()
 
 	// Check if the file exists
 	exists, err := h.fs.Exists(ctx, URI)
@@ -74,21 +80,79 @@ func (h *FileHandler) DownloadHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Download the file content
-	data, err := h.fs.Download(ctx, URI)
+	content, err := h.fs.Open(ctx, URI)
 	if err != nil {
-		log.Printf("Error downloading file: %v", err)
+		log.Printf("Error opening file: %v", err)
 		http.Error(w, "Unable to download file", http.StatusInternalServerError)
 		return
 	}
+	defer content.Close()
 
 	// Determine the file content type
 	contentType := mime.TypeByExtension(filepath.Ext(URI))
 	if contentType == "" {
 		contentType = "application/octet-stream" // Default binary type if unknown
 	}
-	w.Header().Set("Content-Type", contentType)
-	w.Header().Set("Content-Disposition", "attachment; filename="+filepath.Base(URI))
-	w.WriteHeader(http.StatusOK)
-	w.Write(data)
+	disposition := "attachment"
+	if r.URL.Query().Get("disposition") == "inline" {
+		disposition = "inline"
+	}
+	header := w.Header()
+	header.Set("Content-Type", contentType)
+	header.Set("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": filepath.Base(URI)}))
+	header.Set("ETag", fmt.Sprintf(`"%x-%x"`, content.ModTime.UnixNano(), content.Size))
+	http.ServeContent(w, r, "", content.ModTime, seekableContent(r, content))
+}
+
+// seekableContent adapts content to http.ServeContent. Storage readers that
+// cannot seek are skipped forward instead, which serves a single range but
+// not multipart ranges, so those requests get the whole file.
+func seekableContent(r *http.Request, content *file.Content) io.ReadSeeker {
+	if seeker, ok := content.ReadCloser.(io.ReadSeeker); ok {
+		return seeker
+	}
+	if strings.Contains(r.Header.Get("Range"), ",") {
+		r.Header.Del("Range")
+	}
+	return &forwardSeeker{reader: content, size: content.Size}
+}
+
+// forwardSeeker implements io.Seeker over a stream of known size by
+// discarding bytes up to the requested offset on the next Read.
+type forwardSeeker struct {
+	reader   io.Reader
+	size     int64
+	offset   int64
+	position int64
+}
+
+func (s *forwardSeeker) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += s.offset
+	case io.SeekEnd:
+		offset += s.size
+	}
+	if offset < 0 {
+		return 0, errors.New("negative offset")
+	}
+	s.offset = offset
+	return offset, nil
+}
+
+func (s *forwardSeeker) Read(data []byte) (int, error) {
+	if s.offset < s.position {
+		return 0, errors.New("stream cannot seek backwards")
+	}
+	if s.offset > s.position {
+		skipped, err := io.CopyN(io.Discard, s.reader, s.offset-s.position)
+		s.position += skipped
+		if err != nil {
+			return 0, err
+		}
+	}
+	n, err := s.reader.Read(data)
+	s.position += int64(n)
+	s.offset = s.position
+	return n, err
 }
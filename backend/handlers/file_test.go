@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/viant/forge/backend/service/file"
+)
+
+func TestFileHandler_DownloadServesRanges(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "report.pdf"), []byte("0123456789"), 0o644); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	handler := NewFileBrowser(file.New(root)).DownloadHandler
+
+	recorder := httptest.NewRecorder()
+	handler(recorder, httptest.NewRequest(http.MethodGet, "/download?uri=report.pdf", nil))
+	etag := recorder.Header().Get("ETag")
+	if recorder.Code != http.StatusOK || recorder.Body.String() != "0123456789" || recorder.Header().Get("Content-Length") != "10" {
+		t.Fatalf("unexpected full download %d %q %v", recorder.Code, recorder.Body.String(), recorder.Header())
+	}
+	if etag == "" || recorder.Header().Get("Last-Modified") == "" || recorder.Header().Get("Accept-Ranges") != "bytes" {
+		t.Fatalf("expected validators and range support, got %v", recorder.Header())
+	}
+	if got := recorder.Header().Get("Content-Disposition"); got != "attachment; filename=report.pdf" {
+		t.Fatalf("unexpected disposition %q", got)
+	}
+
+	testCases := []struct {
+		description string
+		query       string
+		headers     map[string]string
+		status      int
+		body        string
+	}{
+		{description: "range", headers: map[string]string{"Range": "bytes=2-5"}, status: http.StatusPartialContent, body: "2345"},
+		{description: "matching if-range", headers: map[string]string{"Range": "bytes=8-", "If-Range": etag}, status: http.StatusPartialContent, body: "89"},
+		{description: "stale if-range", headers: map[string]string{"Range": "bytes=8-", "If-Range": `"stale"`}, status: http.StatusOK, body: "0123456789"},
+		{description: "not modified", headers: map[string]string{"If-None-Match": etag}, status: http.StatusNotModified},
+		{description: "inline", query: "&disposition=inline", status: http.StatusOK, body: "0123456789"},
+	}
+	for _, testCase := range testCases {
+		request := httptest.NewRequest(http.MethodGet, "/download?uri=report.pdf"+testCase.query, nil)
+		for key, value := range testCase.headers {
+			request.Header.Set(key, value)
+		}
+		recorder := httptest.NewRecorder()
+		handler(recorder, request)
+		if recorder.Code != testCase.status || recorder.Body.String() != testCase.body {
+			t.Fatalf("%s: expected %d %q, got %d %q", testCase.description, testCase.status, testCase.body, recorder.Code, recorder.Body.String())
+		}
+		if testCase.query != "" && !strings.HasPrefix(recorder.Header().Get("Content-Disposition"), "inline;") {
+			t.Fatalf("%s: unexpected disposition %q", testCase.description, recorder.Header().Get("Content-Disposition"))
+		}
+	}
+}
+
+func TestForwardSeeker_SkipsToOffset(t *testing.T) {
+	seeker := &forwardSeeker{reader: strings.NewReader("0123456789"), size: 10}
+	if size, err := seeker.Seek(0, io.SeekEnd); err != nil || size != 10 {
+		t.Fatalf("expected size 10, got %d (%v)", size, err)
+	}
+	if _, err := seeker.Seek(4, io.SeekStart); err != nil {
+		t.Fatalf("Seek() error = %v", err)
+	}
+	data := make([]byte, 3)
+	if _, err := io.ReadFull(seeker, data); err != nil || string(data) != "456" {
+		t.Fatalf("expected 456, got %q (%v)", data, err)
+	}
+	if _, err := seeker.Seek(0, io.SeekStart); err != nil {
+		t.Fatalf("Seek() error = %v", err)
+	}
+	if _, err := seeker.Read(data); err == nil {
+		t.Fatalf("expected error reading before the stream position")
+	}
+}
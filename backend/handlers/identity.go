@@ -0,0 +1,25 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/viant/forge/backend/service/identity"
+)
+
+// IdentityHandler resolves the caller from the Authorization header with
+// parser and stores it in the request context, where the metadata handlers
+// evaluate visibleFor/requires against it. A request without a token
+// continues anonymously; an invalid token is rejected with 401.
+func IdentityHandler(parser *identity.Parser, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		caller, err := parser.FromRequest(r)
+		if err != nil {
+			writeProblem(w, &Problem{Type: "about:blank", Title: "Invalid credentials", Status: http.StatusUnauthorized, Detail: err.Error(), Instance: r.URL.Path})
+			return
+		}
+		if caller != nil {
+			r = r.WithContext(identity.WithContext(r.Context(), caller))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
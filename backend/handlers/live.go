@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/viant/forge/backend/service/meta"
+)
+
+const (
+	liveHeartbeat    = 30 * time.Second
+	liveClientBuffer = 16
+)
+
+// LiveReload pushes window change notifications to connected clients as
+// server-sent events, so the frontend can hot-reload windows while their
+// metadata is edited. Each client subscribes with the usual target query
+// parameters and only hears about changes affecting its target:
+//
+//	event: window
+//	data: {"key":"order","targets":["web/desktop"],"files":["order/shared/table.yaml"]}
+type LiveReload struct {
+	loader  *meta.Service
+	watcher *meta.ChangeWatcher
+
+	mu      sync.Mutex
+	clients map[*liveClient]struct{}
+}
+
+type liveClient struct {
+	target *meta.TargetContext
+	events chan meta.WindowChange
+}
+
+func NewLiveReload(loader *meta.Service, options ...meta.ChangeWatchOption) *LiveReload {
+	return &LiveReload{
+		loader:  loader,
+		watcher: meta.NewChangeWatcher(loader, options...),
+		clients: map[*liveClient]struct{}{},
+	}
+}
+
+// Start watches the loader's local metadata roots.
+func (l *LiveReload) Start(ctx context.Context) error {
+	return l.watcher.Start(ctx, func(files []string, err error) {
+		if err == nil {
+			l.publish(ctx, files)
+		}
+	})
+}
+
+func (l *LiveReload) Close() error {
+	return l.watcher.Close()
+}
+
+// Handler serves the event stream.
+func (l *LiveReload) Handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+			return
+		}
+		client := &liveClient{target: targetContextFromRequest(r), events: make(chan meta.WindowChange, liveClientBuffer)}
+		l.mu.Lock()
+		l.clients[client] = struct{}{}
+		l.mu.Unlock()
+		defer func() {
+			l.mu.Lock()
+			delete(l.clients, client)
+			l.mu.Unlock()
+		}()
+
+		header := w.Header()
+		header.Set("Content-Type", "text/event-stream")
+		header.Set("Cache-Control", "no-cache")
+		header.Set("Connection", "keep-alive")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, ": connected\n\n")
+		flusher.Flush()
+
+		heartbeat := time.NewTicker(liveHeartbeat)
+		defer heartbeat.Stop()
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-heartbeat.C:
+				fmt.Fprint(w, ": ping\n\n")
+				flusher.Flush()
+			case change := <-client.events:
+				data, err := json.Marshal(change)
+				if err != nil {
+					continue
+				}
+				fmt.Fprintf(w, "event: window\ndata: %s\n\n", data)
+				flusher.Flush()
+			}
+		}
+	}
+}
+
+// publish maps files to windows for the targets of connected clients and
+// notifies each affected client. A client too slow to drain its buffer
+// misses the event rather than blocking others.
+func (l *LiveReload) publish(ctx context.Context, files []string) {
+	l.mu.Lock()
+	clients := make([]*liveClient, 0, len(l.clients))
+	for client := range l.clients {
+		clients = append(clients, client)
+	}
+	l.mu.Unlock()
+	if len(clients) == 0 {
+		return
+	}
+	var targets []*meta.TargetContext
+	seen := map[string]bool{}
+	for _, client := range clients {
+		if name := client.target.String(); !seen[name] {
+			seen[name] = true
+			targets = append(targets, client.target)
+		}
+	}
+	keys, err := l.loader.WindowKeys(ctx, "")
+	if err != nil {
+		return
+	}
+	// Navigation resolves like a single-file window.
+	keys = append(keys, "navigation")
+	changes := l.loader.AffectedWindows(ctx, files, normalizePrefetchKeys(keys), targets)
+	for _, change := range changes {
+		for _, client := range clients {
+			// Clients without a target hear about every change.
+			name := client.target.String()
+			if len(change.Targets) > 0 && name != "" && !containsTarget(change.Targets, name) {
+				continue
+			}
+			select {
+			case client.events <- change:
+			default:
+			}
+		}
+	}
+}
+
+func containsTarget(targets []string, name string) bool {
+	for _, target := range targets {
+		if target == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/viant/afs"
+	"github.com/viant/forge/backend/service/meta"
+)
+
+func TestLiveReload_PushesWindowChangesForClientTarget(t *testing.T) {
+	root := t.TempDir()
+	mustWriteHandlerMetaFile(t, filepath.Join(root, "order", "shared", "main.yaml"), "namespace: Order\nview:\n  content:\n    table: $import(table.yaml)\n")
+	mustWriteHandlerMetaFile(t, filepath.Join(root, "order", "shared", "table.yaml"), "width: 100%\n")
+	mustWriteHandlerMetaFile(t, filepath.Join(root, "customer", "shared", "main.yaml"), "namespace: Customer\n")
+
+	live := NewLiveReload(meta.New(afs.New(), root), meta.WithChangeDebounce(20*time.Millisecond))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := live.Start(ctx); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer live.Close()
+	server := httptest.NewServer(live.Handler())
+	defer server.Close()
+
+	request, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"?platform=web&formFactor=desktop", nil)
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	defer response.Body.Close()
+	if got := response.Header.Get("Content-Type"); got != "text/event-stream" {
+		t.Fatalf("expected event stream, got %q", got)
+	}
+	reader := bufio.NewReader(response.Body)
+	if line, _ := reader.ReadString('\n'); !strings.HasPrefix(line, ": connected") {
+		t.Fatalf("expected connected comment, got %q", line)
+	}
+
+	mustWriteHandlerMetaFile(t, filepath.Join(root, "order", "shared", "table.yaml"), "width: 50%\n")
+	events := make(chan meta.WindowChange, 1)
+	go func() {
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			if data, ok := strings.CutPrefix(strings.TrimSpace(line), "data: "); ok {
+				var change meta.WindowChange
+				if json.Unmarshal([]byte(data), &change) == nil {
+					events <- change
+					return
+				}
+			}
+		}
+	}()
+	select {
+	case change := <-events:
+		if change.Key != "order" || len(change.Targets) != 1 || change.Targets[0] != "web/desktop" {
+			t.Fatalf("unexpected change %+v", change)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for window change event")
+	}
+}
@@ -2,7 +2,6 @@ package handlers
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"github.com/viant/afs/url"
 	"github.com/viant/forge/backend/service/meta"
@@ -12,11 +11,13 @@ import (
 )
 
 type WindowResponse struct {
-	Status string        `json:"status"`
-	Data   *types.Window `json:"data"`
+	Status  string        `json:"status"`
+	Data    *types.Window `json:"data"`
+	Version string        `json:"version,omitempty"`
 }
 
-// WindowHandler fetches window data using the file.Service.
+// WindowHandler fetches window data using the file.Service. Responses carry
+// an ETag of the content version and honour If-None-Match.
 func WindowHandler(loader *meta.Service, baseURL string, baseURI string) http.HandlerFunc {
 
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -29,20 +30,26 @@ func WindowHandler(loader *meta.Service, baseURL string, baseURI string) http.Ha
 		subPath := strings.Join(pathParts[1:], "/")
 		aWindow, err := LoadWindow(r.Context(), loader, baseURL, path, subPath, targetContextFromRequest(r))
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			writeLoadProblem(w, r, err)
 			return
 		}
 
+		version, err := ContentVersion(aWindow)
+		if err != nil {
+			writeLoadProblem(w, r, err)
+			return
+		}
 		resp := WindowResponse{
-			Status: "ok",
-			Data:   aWindow,
+			Status:  "ok",
+			Data:    aWindow,
+			Version: version,
 		}
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(resp)
+		writeVersioned(w, r, version, resp)
 	}
 }
 
-// LoadWindow loads window data using the file.Service.
+// LoadWindow loads window data using the file.Service. baseURL may be empty
+// when loader resolves relative paths itself, e.g. over meta.NewWithFS.
 func LoadWindow(ctx context.Context, loader *meta.Service, baseURL, key, subKey string, target *meta.TargetContext) (*types.Window, error) {
 	subPath := "main"
 	if subKey != "" {
@@ -57,7 +64,7 @@ func LoadWindow(ctx context.Context, loader *meta.Service, baseURL, key, subKey
 		return nil, fmt.Errorf("failed to load window for key %s: %w", key, err)
 	}
 	result := &types.Window{}
-	if err := loader.LoadWithTarget(ctx, resolvedBase+".yaml", result, target); err != nil {
+	if err := loader.LoadForTarget(ctx, resolvedBase+".yaml", result, target); err != nil {
 		return nil, fmt.Errorf("failed to load window for key %s: %w", key, err)
 	}
 	assetPath, assetErr := loader.ResolveWindowAsset(ctx, resolvedBase, ".js", target)
@@ -68,7 +75,7 @@ func LoadWindow(ctx context.Context, loader *meta.Service, baseURL, key, subKey
 		assetPath, assetErr = loader.ResolveWindowAsset(ctx, url.Join(baseURL, key), ".js", target)
 	}
 	if assetErr == nil {
-		code, err := loader.Download(context.Background(), assetPath)
+		code, err := loader.Download(ctx, assetPath)
 		if err != nil {
 			return nil, err
 		}
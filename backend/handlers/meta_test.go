@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"testing/fstest"
 
 	"github.com/viant/afs"
 	afsurl "github.com/viant/afs/url"
@@ -91,6 +92,30 @@ func TestLoadWindow_LoadsRootSiblingActionCodeForFolderizedWindow(t *testing.T)
 	}
 }
 
+func TestLoadWindow_LoadsEmbeddedBundleWithOverlay(t *testing.T) {
+	bundle := fstest.MapFS{
+		"window/order/shared/main.yaml": {Data: []byte("namespace: Order\nview:\n  content: {}\n")},
+		"window/order/shared/main.js":   {Data: []byte("(() => ({ bundled: true }))()")},
+	}
+	overlay := t.TempDir()
+	mustWriteHandlerMetaFile(t, filepath.Join(overlay, "order", "shared", "main.js"), "(() => ({ hotfix: true }))()")
+
+	loader, err := meta.NewWithFS(context.Background(), afs.New(), bundle, "window", []string{overlay})
+	if err != nil {
+		t.Fatalf("NewWithFS() error = %v", err)
+	}
+	window, err := LoadWindow(context.Background(), loader, "", "order", "", &meta.TargetContext{Platform: "web"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if window.Namespace != "Order" {
+		t.Fatalf("expected bundled window, got namespace %q", window.Namespace)
+	}
+	if got := window.Actions.Code; !strings.Contains(got, "hotfix") {
+		t.Fatalf("expected overlay action code, got %q", got)
+	}
+}
+
 func mustWriteHandlerMetaFile(t *testing.T, path, content string) {
 	t.Helper()
 	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
@@ -2,7 +2,6 @@ package handlers
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"github.com/viant/forge/backend/service/meta"
 	"github.com/viant/forge/backend/types"
@@ -10,24 +9,31 @@ import (
 )
 
 type NavigationResponse struct {
-	Status string                 `json:"status"`
-	Data   []types.NavigationItem `json:"data"`
+	Status  string                 `json:"status"`
+	Data    []types.NavigationItem `json:"data"`
+	Version string                 `json:"version,omitempty"`
 }
 
 // NavigationHandler fetches navigation data using the metadata service.
+// Like WindowHandler it supports conditional GET by content version.
 func NavigationHandler(loader *meta.Service, baseURL string) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		navigation, err := FetchNavigationData(r.Context(), loader, baseURL, targetContextFromRequest(r))
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			writeLoadProblem(w, r, err)
+			return
+		}
+		version, err := ContentVersion(navigation)
+		if err != nil {
+			writeLoadProblem(w, r, err)
 			return
 		}
 		resp := NavigationResponse{
-			Status: "ok",
-			Data:   navigation,
+			Status:  "ok",
+			Data:    navigation,
+			Version: version,
 		}
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(resp)
+		writeVersioned(w, r, version, resp)
 	}
 }
 
@@ -39,7 +45,7 @@ func FetchNavigationData(ctx context.Context, loader *meta.Service, baseURL stri
 		return nil, fmt.Errorf("failed to load navigation data: %w", err)
 	}
 	var navigation []types.NavigationItem
-	if err := loader.LoadWithTarget(ctx, base+".yaml", &navigation, target); err != nil {
+	if err := loader.LoadForTarget(ctx, base+".yaml", &navigation, target); err != nil {
 		return nil, fmt.Errorf("failed to parse navigation data: %w", err)
 	}
 	return navigation, nil
@@ -2,11 +2,16 @@ package handlers
 
 import (
 	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"testing"
 
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/viant/afs"
+	"github.com/viant/forge/backend/service/identity"
 	"github.com/viant/forge/backend/service/meta"
 )
 
@@ -47,6 +52,89 @@ func TestFetchNavigationData_TargetAwareBranchSelection(t *testing.T) {
 	}
 }
 
+func TestFetchNavigationData_AppliesTargetSpec(t *testing.T) {
+	root := t.TempDir()
+	mustWriteNavigationFile(t, filepath.Join(root, "shared", "navigation.yaml"), `
+- id: orders
+  label: Orders
+  windowKey: orders
+  targetOverrides:
+    mobile:
+      label: My orders
+- id: reports
+  label: Reports
+  windowKey: reports
+  target: web
+`)
+	loader := meta.New(afs.New(), root)
+	items, err := FetchNavigationData(context.Background(), loader, root, &meta.TargetContext{Platform: "android", FormFactor: "phone"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(items) != 1 || items[0].ID != "orders" {
+		t.Fatalf("expected web-only entry to be pruned, got %#v", items)
+	}
+	if items[0].Label != "My orders" || items[0].TargetOverrides != nil {
+		t.Fatalf("expected mobile override to be merged, got %#v", items[0])
+	}
+}
+
+func TestFetchNavigationData_LocalizesLabels(t *testing.T) {
+	root := t.TempDir()
+	mustWriteNavigationFile(t, filepath.Join(root, "i18n", "en.yaml"), "nav:\n  orders: Orders\n")
+	mustWriteNavigationFile(t, filepath.Join(root, "i18n", "de.yaml"), "nav:\n  orders: Bestellungen\n")
+	mustWriteNavigationFile(t, filepath.Join(root, "shared", "navigation.yaml"), "- id: orders\n  label: $t(nav.orders)\n  windowKey: orders\n  windowTitle: {i18n: nav.orders}\n")
+	loader := meta.New(afs.New(), root).ConfigureI18n()
+	items, err := FetchNavigationData(context.Background(), loader, root, &meta.TargetContext{Platform: "web", Locale: "de-AT,de;q=0.9"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(items) != 1 || items[0].Label != "Bestellungen" || items[0].WindowTitle != "Bestellungen" {
+		t.Fatalf("expected German labels, got %#v", items)
+	}
+}
+
+func TestNavigationHandler_FiltersEntriesByCallerRoles(t *testing.T) {
+	root := t.TempDir()
+	mustWriteNavigationFile(t, filepath.Join(root, "shared", "navigation.yaml"), "- id: orders\n  label: Orders\n  windowKey: orders\n- id: admin\n  label: Admin\n  windowKey: admin\n  visibleFor: admin\n")
+	handler := IdentityHandler(identity.NewParser(identity.WithUnverifiedClaims()), NavigationHandler(meta.New(afs.New(), root), root))
+	ids := func(token string) []string {
+		request := httptest.NewRequest(http.MethodGet, "/v1/api/navigation?platform=web", nil)
+		if token != "" {
+			request.Header.Set("Authorization", "Bearer "+token)
+		}
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", recorder.Code, recorder.Body.String())
+		}
+		var response NavigationResponse
+		if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+			t.Fatalf("decode response: %v", err)
+		}
+		var result []string
+		for _, item := range response.Data {
+			result = append(result, item.ID)
+		}
+		return result
+	}
+	if got := ids(""); len(got) != 1 || got[0] != "orders" {
+		t.Fatalf("expected anonymous caller to see orders only, got %v", got)
+	}
+	token, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "u1", "roles": []string{"admin"}}).SignedString([]byte("secret"))
+	if got := ids(token); len(got) != 2 {
+		t.Fatalf("expected admin to see both entries, got %v", got)
+	}
+
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodGet, "/v1/api/navigation?platform=web", nil)
+	request.Header.Set("Authorization", "Bearer not-a-jwt")
+	handler.ServeHTTP(recorder, request)
+	if recorder.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 for invalid token, got %d", recorder.Code)
+	}
+}
+
 func mustWriteNavigationFile(t *testing.T, path, content string) {
 	t.Helper()
 	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
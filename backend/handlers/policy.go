@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/viant/forge/backend/service/file"
+	"github.com/viant/forge/backend/service/identity"
+)
+
+// Upload problem codes, reported in Problem.Code.
+const (
+	UploadTooLarge          = "upload_too_large"
+	UploadTypeNotAllowed    = "upload_type_not_allowed"
+	UploadExtensionRejected = "upload_extension_not_allowed"
+	UploadInvalidName       = "upload_invalid_name"
+	UploadQuotaExceeded     = "upload_quota_exceeded"
+
+	UploadNotFound           = "upload_not_found"
+	UploadNotOwned           = "upload_not_owned"
+	UploadIncomplete         = "upload_incomplete"
+	UploadFinalized          = "upload_finalized"
+	UploadInvalidDestination = "upload_invalid_destination"
+	UploadDestinationExists  = "upload_destination_exists"
+)
+
+// sniffLength is how many leading bytes http.DetectContentType considers.
+const sniffLength = 512
+
+const maxFilenameLength = 255
+
+// UploadPolicy restricts what UploadHandler and ResumableUpload accept. Zero
+// values leave the corresponding check off; filenames are always sanitized.
+type UploadPolicy struct {
+	// MaxBytes bounds the size of one file.
+	MaxBytes int64
+	// AllowedTypes lists media types detected by content sniffing, e.g.
+	// "application/pdf" or "image/*"; the client supplied type is ignored.
+	AllowedTypes []string
+	// AllowedExtensions lists file extensions, e.g. ".csv", case-insensitive.
+	AllowedExtensions []string
+	// Quota bounds the bytes one caller keeps in upload staging. Callers are
+	// told apart by identity subject; anonymous callers share one quota.
+	Quota int64
+}
+
+// PolicyError is an upload request rejected by the upload policy or the
+// staging lifecycle, with the status and code of the problem it is reported
+// as.
+type PolicyError struct {
+	Status int
+	Code   string
+	Detail string
+}
+
+func (e *PolicyError) Error() string {
+	return e.Detail
+}
+
+func (e *PolicyError) problem(r *http.Request) *Problem {
+	return &Problem{Type: "about:blank", Title: "Upload rejected", Status: e.Status, Code: e.Code, Detail: e.Detail, Instance: r.URL.Path}
+}
+
+// SanitizeFilename reduces a client supplied name to a safe base name: no
+// directories, control or reserved characters, leading dots or surrounding
+// spaces, and at most 255 bytes with the extension kept.
+func SanitizeFilename(name string) (string, error) {
+	name = path.Base("/" + strings.ReplaceAll(name, "\\", "/"))
+	var builder strings.Builder
+	for _, r := range name {
+		switch {
+		case unicode.IsControl(r) || r == unicode.ReplacementChar:
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune(" .-_()+,@", r):
+			builder.WriteRune(r)
+		default:
+			builder.WriteRune('_')
+		}
+	}
+	name = strings.TrimLeft(strings.TrimSpace(builder.String()), ". ")
+	name = strings.TrimRight(name, ". ")
+	if strings.Trim(name, "_") == "" {
+		return "", &PolicyError{Status: http.StatusBadRequest, Code: UploadInvalidName, Detail: "file name is empty or invalid"}
+	}
+	if len(name) > maxFilenameLength {
+		ext := path.Ext(name)
+		if len(ext) > maxFilenameLength/2 {
+			ext = ""
+		}
+		base := name[:maxFilenameLength-len(ext)]
+		for !utf8.ValidString(base) {
+			base = base[:len(base)-1]
+		}
+		name = base + ext
+	}
+	return name, nil
+}
+
+// CheckName sanitizes name and applies the extension allow-list.
+func (p *UploadPolicy) CheckName(name string) (string, error) {
+	name, err := SanitizeFilename(name)
+	if err != nil || p == nil || len(p.AllowedExtensions) == 0 {
+		return name, err
+	}
+	ext := strings.ToLower(path.Ext(name))
+	for _, allowed := range p.AllowedExtensions {
+		allowed = strings.ToLower(strings.TrimSpace(allowed))
+		if ext != "" && (allowed == ext || "."+allowed == ext) {
+			return name, nil
+		}
+	}
+	return "", &PolicyError{Status: http.StatusUnsupportedMediaType, Code: UploadExtensionRejected, Detail: fmt.Sprintf("files of type %q are not allowed", ext)}
+}
+
+// CheckSize applies MaxBytes to the size of one file.
+func (p *UploadPolicy) CheckSize(size int64) error {
+	if p == nil || p.MaxBytes <= 0 || size <= p.MaxBytes {
+		return nil
+	}
+	return &PolicyError{Status: http.StatusRequestEntityTooLarge, Code: UploadTooLarge, Detail: fmt.Sprintf("file of %d bytes exceeds the %d byte limit", size, p.MaxBytes)}
+}
+
+// CheckContent sniffs the media type of the leading bytes of a file and
+// applies AllowedTypes.
+func (p *UploadPolicy) CheckContent(head []byte) error {
+	if p == nil || len(p.AllowedTypes) == 0 {
+		return nil
+	}
+	detected, _, _ := mime.ParseMediaType(http.DetectContentType(head))
+	for _, allowed := range p.AllowedTypes {
+		allowed = strings.ToLower(strings.TrimSpace(allowed))
+		if allowed == detected || allowed == "*/*" {
+			return nil
+		}
+		if prefix, ok := strings.CutSuffix(allowed, "/*"); ok && strings.HasPrefix(detected, prefix+"/") {
+			return nil
+		}
+	}
+	return &PolicyError{Status: http.StatusUnsupportedMediaType, Code: UploadTypeNotAllowed, Detail: fmt.Sprintf("content of type %q is not allowed", detected)}
+}
+
+// CheckQuota applies Quota to the caller of ctx storing size more bytes.
+// Usage is summed from the state of every staged upload; UploadHandler and
+// ResumableUpload hold the caller's quota lock until the new upload is
+// recorded, so concurrent uploads cannot overrun it.
+func (p *UploadPolicy) CheckQuota(ctx context.Context, fs *file.Service, size int64) error {
+	if p == nil || p.Quota <= 0 {
+		return nil
+	}
+	used, err := stagedBytes(ctx, fs, uploadOwner(ctx))
+	if err != nil {
+		return err
+	}
+	if used+size <= p.Quota {
+		return nil
+	}
+	return &PolicyError{Status: http.StatusRequestEntityTooLarge, Code: UploadQuotaExceeded, Detail: fmt.Sprintf("upload quota of %d bytes exceeded, %d bytes in use", p.Quota, used)}
+}
+
+// uploadOwner is the identity subject uploads are accounted to, empty for
+// anonymous callers.
+func uploadOwner(ctx context.Context) string {
+	if caller := identity.FromContext(ctx); caller != nil {
+		return caller.Subject
+	}
+	return ""
+}
+
+// stagedBytes sums the declared length of staged uploads owned by owner.
+func stagedBytes(ctx context.Context, fs *file.Service, owner string) (int64, error) {
+	if exists, _ := fs.Exists(ctx, uploadsFolder); !exists {
+		return 0, nil
+	}
+	folders, err := fs.List(ctx, file.WithURI(uploadsFolder), file.WithOnlyFolder(true))
+	if err != nil {
+		return 0, err
+	}
+	var total int64
+	for _, folder := range folders {
+		for _, child := range folder.ChildNodes {
+			data, err := fs.Download(ctx, path.Join(uploadsFolder, path.Base(child.URI), uploadStateFile))
+			if err != nil {
+				continue
+			}
+			state := &uploadState{}
+			if json.Unmarshal(data, state) == nil && state.Owner == owner {
+				total += state.Length
+			}
+		}
+	}
+	return total, nil
+}
+
+// writeUploadError reports a PolicyError as its problem and anything else
+// as a 500.
+func writeUploadError(w http.ResponseWriter, r *http.Request, err error) {
+	var policyErr *PolicyError
+	if errors.As(err, &policyErr) {
+		writeProblem(w, policyErr.problem(r))
+		return
+	}
+	writeUploadProblem(w, r, http.StatusInternalServerError, "Unable to store file", "")
+}
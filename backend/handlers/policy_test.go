@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/viant/forge/backend/service/file"
+	"github.com/viant/forge/backend/service/identity"
+)
+
+func TestSanitizeFilename(t *testing.T) {
+	testCases := []struct {
+		name     string
+		expected string
+		invalid  bool
+	}{
+		{name: "report.pdf", expected: "report.pdf"},
+		{name: "../../etc/passwd", expected: "passwd"},
+		{name: `C:\Users\me\résumé.docx`, expected: "résumé.docx"},
+		{name: ".upload.json", expected: "upload.json"},
+		{name: "a<b>:c|d?.txt\x00", expected: "a_b__c_d_.txt"},
+		{name: strings.Repeat("x", 300) + ".csv", expected: strings.Repeat("x", 251) + ".csv"},
+		{name: "..", invalid: true},
+		{name: "  ", invalid: true},
+	}
+	for _, testCase := range testCases {
+		actual, err := SanitizeFilename(testCase.name)
+		if testCase.invalid {
+			if err == nil {
+				t.Fatalf("%q: expected error, got %q", testCase.name, actual)
+			}
+			continue
+		}
+		if err != nil || actual != testCase.expected {
+			t.Fatalf("%q: expected %q, got %q (%v)", testCase.name, testCase.expected, actual, err)
+		}
+	}
+}
+
+func TestUploadHandler_EnforcesPolicy(t *testing.T) {
+	root := t.TempDir()
+	fs := file.New(root)
+	handler := UploadHandler(fs, WithUploadPolicy(&UploadPolicy{
+		MaxBytes:          64,
+		AllowedTypes:      []string{"text/*", "image/png"},
+		AllowedExtensions: []string{".txt", "png"},
+		Quota:             20,
+	}))
+	upload := func(name, content, subject string) *httptest.ResponseRecorder {
+		body := &bytes.Buffer{}
+		writer := multipart.NewWriter(body)
+		part, _ := writer.CreateFormFile("file", name)
+		part.Write([]byte(content))
+		writer.Close()
+		request := httptest.NewRequest(http.MethodPost, "/upload", body)
+		request.Header.Set("Content-Type", writer.FormDataContentType())
+		if subject != "" {
+			request = request.WithContext(identity.WithContext(request.Context(), &identity.Identity{Subject: subject}))
+		}
+		recorder := httptest.NewRecorder()
+		handler(recorder, request)
+		return recorder
+	}
+
+	recorder := upload("../notes.txt", "hello world", "alice")
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected upload to succeed, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	var uploaded UploadedFile
+	if err := json.Unmarshal(recorder.Body.Bytes(), &uploaded); err != nil || uploaded.Name != "notes.txt" {
+		t.Fatalf("unexpected upload %+v (%v)", uploaded, err)
+	}
+	if data, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(uploaded.URI))); err != nil || string(data) != "hello world" {
+		t.Fatalf("expected stored file, got %q (%v)", data, err)
+	}
+
+	testCases := []struct {
+		description string
+		name        string
+		content     string
+		subject     string
+		status      int
+		code        string
+	}{
+		{description: "extension", name: "run.sh", content: "echo", status: http.StatusUnsupportedMediaType, code: UploadExtensionRejected},
+		{description: "sniffed type", name: "image.png", content: "%PDF-1.7 not an image", status: http.StatusUnsupportedMediaType, code: UploadTypeNotAllowed},
+		{description: "size", name: "big.txt", content: strings.Repeat("a", 65), status: http.StatusRequestEntityTooLarge, code: UploadTooLarge},
+		{description: "body over the size limit", name: "huge.txt", content: strings.Repeat("a", multipartOverhead+65), status: http.StatusRequestEntityTooLarge, code: UploadTooLarge},
+		{description: "quota", name: "more.txt", content: "0123456789", subject: "alice", status: http.StatusRequestEntityTooLarge, code: UploadQuotaExceeded},
+		{description: "empty name", name: "...", content: "text", status: http.StatusBadRequest, code: UploadInvalidName},
+	}
+	for _, testCase := range testCases {
+		recorder := upload(testCase.name, testCase.content, testCase.subject)
+		if recorder.Code != testCase.status {
+			t.Fatalf("%s: expected %d, got %d: %s", testCase.description, testCase.status, recorder.Code, recorder.Body.String())
+		}
+		var problem Problem
+		if err := json.Unmarshal(recorder.Body.Bytes(), &problem); err != nil || problem.Code != testCase.code || problem.Detail == "" {
+			t.Fatalf("%s: unexpected problem %s (%v)", testCase.description, recorder.Body.String(), err)
+		}
+	}
+	if recorder := upload("more.txt", "0123456789", "bob"); recorder.Code != http.StatusOK {
+		t.Fatalf("expected another caller's quota to be separate, got %d", recorder.Code)
+	}
+	if entries, _ := os.ReadDir(filepath.Join(root, "uploads")); len(entries) != 2 {
+		t.Fatalf("expected rejected uploads not to be stored, got %d staging folders", len(entries))
+	}
+}
+
+func TestUploadHandler_QuotaHoldsUnderConcurrentUploads(t *testing.T) {
+	root := t.TempDir()
+	handler := UploadHandler(file.New(root), WithUploadPolicy(&UploadPolicy{Quota: 10}))
+	upload := func(subject string) int {
+		body := &bytes.Buffer{}
+		writer := multipart.NewWriter(body)
+		part, _ := writer.CreateFormFile("file", "notes.txt")
+		part.Write([]byte("0123456789"))
+		writer.Close()
+		request := httptest.NewRequest(http.MethodPost, "/upload", body)
+		request.Header.Set("Content-Type", writer.FormDataContentType())
+		if subject != "" {
+			request = request.WithContext(identity.WithContext(request.Context(), &identity.Identity{Subject: subject}))
+		}
+		recorder := httptest.NewRecorder()
+		handler(recorder, request)
+		return recorder.Code
+	}
+
+	testCases := []struct {
+		description string
+		subject     string
+	}{
+		{description: "same caller", subject: "alice"},
+		{description: "anonymous callers", subject: ""},
+	}
+	for _, testCase := range testCases {
+		statuses := make(chan int, 8)
+		var group sync.WaitGroup
+		for i := 0; i < cap(statuses); i++ {
+			group.Add(1)
+			go func() {
+				defer group.Done()
+				statuses <- upload(testCase.subject)
+			}()
+		}
+		group.Wait()
+		close(statuses)
+		accepted := 0
+		for status := range statuses {
+			switch status {
+			case http.StatusOK:
+				accepted++
+			case http.StatusRequestEntityTooLarge:
+			default:
+				t.Fatalf("%s: unexpected status %d", testCase.description, status)
+			}
+		}
+		if accepted != 1 {
+			t.Fatalf("%s: expected exactly one upload within the quota, got %d", testCase.description, accepted)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/viant/forge/backend/service/meta"
+	"github.com/viant/forge/backend/types"
+)
+
+// DefaultPrefetchParallelism bounds concurrent window loads when the
+// handler is created with a non-positive parallelism.
+const DefaultPrefetchParallelism = 8
+
+// MaxPrefetchKeys bounds the windows one prefetch request may load,
+// navigation keys included; larger requests are rejected with 413.
+const MaxPrefetchKeys = 100
+
+// maxPrefetchBody bounds the JSON body of a POST prefetch request.
+const maxPrefetchBody = 64 << 10
+
+// PrefetchRequest lists the windows to load. Keys may carry a sub key like
+// the window endpoint ("order/detail"); Navigation adds every window key
+// reachable from the navigation tree for the target.
+type PrefetchRequest struct {
+	Keys       []string `json:"keys,omitempty"`
+	Navigation bool     `json:"navigation,omitempty"`
+}
+
+// PrefetchItem is the outcome for one window: Data and Version on success,
+// Error otherwise.
+type PrefetchItem struct {
+	Key     string        `json:"key"`
+	Status  string        `json:"status"`
+	Data    *types.Window `json:"data,omitempty"`
+	Version string        `json:"version,omitempty"`
+	Error   *Problem      `json:"error,omitempty"`
+}
+
+type PrefetchResponse struct {
+	Status string         `json:"status"`
+	Data   []PrefetchItem `json:"data"`
+}
+
+// PrefetchHandler loads many windows for one target in a single response.
+// Keys come from a JSON PrefetchRequest body (POST) or from repeated or
+// comma separated keys query parameters plus navigation=true (GET). A window
+// that fails to load is reported in its item and does not fail the batch.
+func PrefetchHandler(loader *meta.Service, baseURL string, parallelism int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		r.Body = http.MaxBytesReader(w, r.Body, maxPrefetchBody)
+		request, err := prefetchRequestFromHTTP(r)
+		if err != nil {
+			status := http.StatusBadRequest
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				status = http.StatusRequestEntityTooLarge
+			}
+			writeProblem(w, &Problem{Type: "about:blank", Title: "Invalid prefetch request", Status: status, Detail: err.Error(), Instance: r.URL.Path})
+			return
+		}
+		target := targetContextFromRequest(r)
+		keys := request.Keys
+		if request.Navigation {
+			navigation, err := FetchNavigationData(r.Context(), loader, baseURL, target)
+			if err != nil {
+				writeLoadProblem(w, r, err)
+				return
+			}
+			keys = append(keys, NavigationWindowKeys(navigation)...)
+		}
+		keys = normalizePrefetchKeys(keys)
+		if len(keys) == 0 {
+			writeProblem(w, &Problem{Type: "about:blank", Title: "Invalid prefetch request", Status: http.StatusBadRequest, Detail: "no window keys requested", Instance: r.URL.Path})
+			return
+		}
+		if len(keys) > MaxPrefetchKeys {
+			writeProblem(w, &Problem{Type: "about:blank", Title: "Invalid prefetch request", Status: http.StatusRequestEntityTooLarge, Detail: fmt.Sprintf("%d window keys requested, at most %d are allowed", len(keys), MaxPrefetchKeys), Instance: r.URL.Path})
+			return
+		}
+		resp := PrefetchResponse{
+			Status: "ok",
+			Data:   PrefetchWindows(r.Context(), loader, baseURL, keys, target, parallelism),
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Add("Vary", "Accept-Language, Authorization")
+		json.NewEncoder(w).Encode(resp)
+	}
+}
+
+// PrefetchWindows loads keys with LoadWindow, at most parallelism at a time,
+// and returns one item per key in request order.
+func PrefetchWindows(ctx context.Context, loader *meta.Service, baseURL string, keys []string, target *meta.TargetContext, parallelism int) []PrefetchItem {
+	if parallelism <= 0 {
+		parallelism = DefaultPrefetchParallelism
+	}
+	result := make([]PrefetchItem, len(keys))
+	slots := make(chan struct{}, parallelism)
+	var wg sync.WaitGroup
+	for i, key := range keys {
+		slots <- struct{}{}
+		wg.Add(1)
+		go func(i int, key string) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			result[i] = prefetchWindow(ctx, loader, baseURL, key, target)
+		}(i, key)
+	}
+	wg.Wait()
+	return result
+}
+
+func prefetchWindow(ctx context.Context, loader *meta.Service, baseURL, key string, target *meta.TargetContext) PrefetchItem {
+	result := PrefetchItem{Key: key, Status: "error"}
+	if err := ctx.Err(); err != nil {
+		result.Error = newLoadProblem(key, err)
+		return result
+	}
+	windowKey, subKey, _ := strings.Cut(key, "/")
+	aWindow, err := LoadWindow(ctx, loader, baseURL, windowKey, subKey, target)
+	if err == nil {
+		result.Version, err = ContentVersion(aWindow)
+	}
+	if err != nil {
+		result.Error = newLoadProblem(key, err)
+		return result
+	}
+	result.Status, result.Data = "ok", aWindow
+	return result
+}
+
+// NavigationWindowKeys returns the distinct window keys in items and their
+// child nodes, depth first.
+func NavigationWindowKeys(items []types.NavigationItem) []string {
+	var result []string
+	var visit func(items []types.NavigationItem)
+	visit = func(items []types.NavigationItem) {
+		for _, item := range items {
+			if key := strings.TrimSpace(item.WindowKey); key != "" {
+				result = append(result, key)
+			}
+			visit(item.ChildNodes)
+		}
+	}
+	visit(items)
+	return normalizePrefetchKeys(result)
+}
+
+func prefetchRequestFromHTTP(r *http.Request) (*PrefetchRequest, error) {
+	result := &PrefetchRequest{}
+	if r.Method == http.MethodPost {
+		if err := json.NewDecoder(r.Body).Decode(result); err != nil {
+			return nil, err
+		}
+		return result, nil
+	}
+	query := r.URL.Query()
+	result.Keys = listValuesFromQuery(query["keys"])
+	result.Navigation = query.Get("navigation") == "true"
+	return result, nil
+}
+
+func normalizePrefetchKeys(keys []string) []string {
+	result := make([]string, 0, len(keys))
+	seen := map[string]bool{}
+	for _, key := range keys {
+		key = strings.Trim(strings.TrimSpace(key), "/")
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		result = append(result, key)
+	}
+	return result
+}
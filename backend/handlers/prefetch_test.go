@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/viant/afs"
+	"github.com/viant/forge/backend/service/meta"
+	"github.com/viant/forge/backend/types"
+)
+
+func TestPrefetchHandler_LoadsWindowsWithPerWindowErrors(t *testing.T) {
+	root := t.TempDir()
+	base := filepath.Join(root, "window")
+	mustWriteHandlerMetaFile(t, filepath.Join(base, "order", "shared", "main.yaml"), "namespace: Order\n")
+	mustWriteHandlerMetaFile(t, filepath.Join(base, "order", "detail", "shared", "main.yaml"), "namespace: Order detail\n")
+	mustWriteHandlerMetaFile(t, filepath.Join(base, "customer.yaml"), "namespace: Customer\n")
+	mustWriteHandlerMetaFile(t, filepath.Join(base, "shared", "navigation.yaml"), "- id: sales\n  label: Sales\n  childNodes:\n    - id: orders\n      label: Orders\n      windowKey: order\n    - id: customers\n      label: Customers\n      windowKey: customer\n")
+	baseURL := "file://" + filepath.ToSlash(base)
+	handler := PrefetchHandler(meta.New(afs.New(), baseURL), baseURL, 2)
+
+	body, _ := json.Marshal(PrefetchRequest{Keys: []string{"missing", "order/detail"}, Navigation: true})
+	recorder := httptest.NewRecorder()
+	handler(recorder, httptest.NewRequest(http.MethodPost, "/v1/api/prefetch?platform=web", bytes.NewReader(body)))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	var response PrefetchResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	var keys, statuses []string
+	for _, item := range response.Data {
+		keys = append(keys, item.Key)
+		statuses = append(statuses, item.Status)
+	}
+	if !reflect.DeepEqual(keys, []string{"missing", "order/detail", "order", "customer"}) {
+		t.Fatalf("unexpected keys %v", keys)
+	}
+	if !reflect.DeepEqual(statuses, []string{"error", "ok", "ok", "ok"}) {
+		t.Fatalf("unexpected statuses %v", statuses)
+	}
+	if problem := response.Data[0].Error; problem == nil || problem.Status != http.StatusNotFound {
+		t.Fatalf("expected 404 problem for missing window, got %#v", problem)
+	}
+	if item := response.Data[1]; item.Data.Namespace != "Order detail" || item.Version == "" {
+		t.Fatalf("expected versioned sub window, got %#v", item)
+	}
+
+	recorder = httptest.NewRecorder()
+	handler(recorder, httptest.NewRequest(http.MethodGet, "/v1/api/prefetch?platform=web", nil))
+	if recorder.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 without keys, got %d", recorder.Code)
+	}
+
+	tooMany := make([]string, MaxPrefetchKeys+1)
+	for i := range tooMany {
+		tooMany[i] = fmt.Sprintf("window%d", i)
+	}
+	body, _ = json.Marshal(PrefetchRequest{Keys: tooMany})
+	recorder = httptest.NewRecorder()
+	handler(recorder, httptest.NewRequest(http.MethodPost, "/v1/api/prefetch?platform=web", bytes.NewReader(body)))
+	if recorder.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected 413 over the key limit, got %d", recorder.Code)
+	}
+
+	body, _ = json.Marshal(PrefetchRequest{Keys: []string{strings.Repeat("x", maxPrefetchBody)}})
+	recorder = httptest.NewRecorder()
+	handler(recorder, httptest.NewRequest(http.MethodPost, "/v1/api/prefetch?platform=web", bytes.NewReader(body)))
+	if recorder.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected 413 over the body limit, got %d", recorder.Code)
+	}
+}
+
+func TestNavigationWindowKeys_DedupsDepthFirst(t *testing.T) {
+	items := []types.NavigationItem{
+		{WindowKey: "a", ChildNodes: []types.NavigationItem{{WindowKey: "b"}, {WindowKey: "a"}}},
+		{ChildNodes: []types.NavigationItem{{WindowKey: " c "}}},
+	}
+	if keys := NavigationWindowKeys(items); !reflect.DeepEqual(keys, []string{"a", "b", "c"}) {
+		t.Fatalf("unexpected keys %v", keys)
+	}
+}
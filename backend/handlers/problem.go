@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"net/http"
+
+	"github.com/viant/forge/backend/service/meta"
+)
+
+// Problem is an RFC 7807 problem document. Load carries the metadata file,
+// position and $import chain when the failure came from meta.Service;
+// Diagnostics lists strict validation findings; Code names the violated
+// rule for clients that branch on it, e.g. upload policy violations.
+type Problem struct {
+	Type        string            `json:"type"`
+	Title       string            `json:"title"`
+	Status      int               `json:"status"`
+	Detail      string            `json:"detail,omitempty"`
+	Instance    string            `json:"instance,omitempty"`
+	Code        string            `json:"code,omitempty"`
+	Load        *meta.LoadError   `json:"load,omitempty"`
+	Diagnostics []meta.Diagnostic `json:"diagnostics,omitempty"`
+}
+
+// writeLoadProblem reports a metadata load failure as application/problem+json.
+// Missing windows map to 404, strict validation failures to 422 and
+// everything else to 500.
+func writeLoadProblem(w http.ResponseWriter, r *http.Request, err error) {
+	writeProblem(w, newLoadProblem(r.URL.Path, err))
+}
+
+// newLoadProblem maps a metadata load failure to a Problem; see
+// writeLoadProblem.
+func newLoadProblem(instance string, err error) *Problem {
+	problem := &Problem{
+		Type:     "about:blank",
+		Title:    "Metadata load failed",
+		Status:   http.StatusInternalServerError,
+		Detail:   err.Error(),
+		Instance: instance,
+	}
+	if errors.Is(err, fs.ErrNotExist) {
+		problem.Title = "Metadata not found"
+		problem.Status = http.StatusNotFound
+	}
+	var validationErr *meta.ValidationError
+	if errors.As(err, &validationErr) {
+		problem.Title = "Metadata validation failed"
+		problem.Status = http.StatusUnprocessableEntity
+		problem.Diagnostics = validationErr.Diagnostics
+	}
+	var loadErr *meta.LoadError
+	if errors.As(err, &loadErr) {
+		problem.Load = loadErr
+	}
+	return problem
+}
+
+func writeProblem(w http.ResponseWriter, problem *Problem) {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(problem.Status)
+	_ = json.NewEncoder(w).Encode(problem)
+}
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/viant/afs"
+	"github.com/viant/forge/backend/service/meta"
+)
+
+func TestWindowHandler_ReportsLoadProblem(t *testing.T) {
+	root := t.TempDir()
+	base := filepath.Join(root, "window")
+	mustWriteHandlerMetaFile(t, filepath.Join(base, "order", "shared", "main.yaml"), "namespace: order\nview:\n  content: $import(content.yaml)\n")
+
+	baseURL := "file://" + filepath.ToSlash(base)
+	handler := WindowHandler(meta.New(afs.New(), baseURL), baseURL, "/v1/api/window/")
+
+	testCases := []struct {
+		description string
+		path        string
+		status      int
+		hasLine     bool
+	}{
+		{description: "broken import", path: "/v1/api/window/order", status: http.StatusInternalServerError, hasLine: true},
+		{description: "missing window", path: "/v1/api/window/missing", status: http.StatusNotFound},
+	}
+	for _, testCase := range testCases {
+		recorder := httptest.NewRecorder()
+		handler(recorder, httptest.NewRequest(http.MethodGet, testCase.path+"?platform=web", nil))
+		if recorder.Code != testCase.status {
+			t.Fatalf("%s: expected status %d, got %d", testCase.description, testCase.status, recorder.Code)
+		}
+		if got := recorder.Header().Get("Content-Type"); got != "application/problem+json" {
+			t.Fatalf("%s: unexpected content type %q", testCase.description, got)
+		}
+		var problem struct {
+			Status int `json:"status"`
+			Load   struct {
+				URL     string `json:"url"`
+				Line    int    `json:"line"`
+				Message string `json:"message"`
+			} `json:"load"`
+		}
+		if err := json.Unmarshal(recorder.Body.Bytes(), &problem); err != nil {
+			t.Fatalf("%s: invalid problem document: %v", testCase.description, err)
+		}
+		if problem.Status != testCase.status || problem.Load.Message == "" {
+			t.Fatalf("%s: unexpected problem %s", testCase.description, recorder.Body.String())
+		}
+		if testCase.hasLine && (problem.Load.Line != 3 || !strings.HasSuffix(problem.Load.URL, "/order/shared/main.yaml")) {
+			t.Fatalf("%s: expected directive position, got %s", testCase.description, recorder.Body.String())
+		}
+	}
+}
+
+func TestWindowHandler_ReportsStrictValidationDiagnostics(t *testing.T) {
+	root := t.TempDir()
+	base := filepath.Join(root, "window")
+	mustWriteHandlerMetaFile(t, filepath.Join(base, "order", "shared", "main.yaml"), "namespace: order\nview:\n  content:\n    id: main\n    dataSourceRef: orders\n")
+
+	baseURL := "file://" + filepath.ToSlash(base)
+	handler := WindowHandler(meta.New(afs.New(), baseURL).EnableStrict(), baseURL, "/v1/api/window/")
+	recorder := httptest.NewRecorder()
+	handler(recorder, httptest.NewRequest(http.MethodGet, "/v1/api/window/order?platform=web", nil))
+	if recorder.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected status 422, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	var problem Problem
+	if err := json.Unmarshal(recorder.Body.Bytes(), &problem); err != nil {
+		t.Fatalf("invalid problem document: %v", err)
+	}
+	if len(problem.Diagnostics) != 1 || problem.Diagnostics[0].Code != "dataSourceNotFound" || problem.Diagnostics[0].YAMLPath != "$.view.content.dataSourceRef" {
+		t.Fatalf("unexpected diagnostics %s", recorder.Body.String())
+	}
+}
@@ -0,0 +1,527 @@
+package handlers
+
+import (
+	"bufio"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"log"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/viant/forge/backend/service/file"
+)
+
+const (
+	// DefaultMaxUploadSize bounds the declared Upload-Length.
+	DefaultMaxUploadSize = 1 << 30
+	// DefaultMaxChunkSize bounds a single PATCH body.
+	DefaultMaxChunkSize = 32 << 20
+
+	tusVersion             = "1.0.0"
+	offsetContentType      = "application/offset+octet-stream"
+	statusChecksumMismatch = 460
+	uploadsFolder          = "uploads"
+	uploadStateFile        = ".upload.json"
+	uploadChunksFolder     = ".chunks"
+)
+
+// UploadedFile describes a file stored in an upload staging folder.
+type UploadedFile struct {
+	Name          string `json:"name"`
+	Size          int64  `json:"size"`
+	URI           string `json:"uri"`
+	StagingFolder string `json:"stagingFolder"`
+}
+
+// UploadOption configures UploadHandler and ResumableUpload.
+type UploadOption func(*uploadConfig)
+
+type uploadConfig struct {
+	maxSize  int64
+	maxChunk int64
+	policy   *UploadPolicy
+	staging  *Staging
+}
+
+// WithMaxUploadSize sets the largest file accepted.
+func WithMaxUploadSize(size int64) UploadOption {
+	return func(c *uploadConfig) {
+		c.maxSize = size
+	}
+}
+
+// WithMaxChunkSize sets the largest PATCH body accepted.
+func WithMaxChunkSize(size int64) UploadOption {
+	return func(c *uploadConfig) {
+		c.maxChunk = size
+	}
+}
+
+// WithUploadPolicy enforces policy before files are stored.
+func WithUploadPolicy(policy *UploadPolicy) UploadOption {
+	return func(c *uploadConfig) {
+		c.policy = policy
+	}
+}
+
+// WithStaging makes ResumableUpload commit, discard and lock uploads through
+// staging, and both upload handlers reserve quota through it, so uploads and
+// the sweeper of the host share one TTL and lock set. By default each
+// handler builds its own with NewStaging.
+func WithStaging(staging *Staging) UploadOption {
+	return func(c *uploadConfig) {
+		c.staging = staging
+	}
+}
+
+func newUploadConfig(options ...UploadOption) *uploadConfig {
+	result := &uploadConfig{maxSize: DefaultMaxUploadSize, maxChunk: DefaultMaxChunkSize}
+	for _, option := range options {
+		if option != nil {
+			option(result)
+		}
+	}
+	return result
+}
+
+// sizeLimit returns the effective size limit of one file, the lower of the
+// configured limit and the policy's MaxBytes, or 0 when neither is set.
+func (c *uploadConfig) sizeLimit() int64 {
+	limit := c.maxSize
+	if c.policy != nil && c.policy.MaxBytes > 0 && (limit <= 0 || c.policy.MaxBytes < limit) {
+		limit = c.policy.MaxBytes
+	}
+	return limit
+}
+
+// checkSize applies the size limit and the policy to the size of one file.
+func (c *uploadConfig) checkSize(size int64) error {
+	if c.maxSize > 0 && size > c.maxSize {
+		return &PolicyError{Status: http.StatusRequestEntityTooLarge, Code: UploadTooLarge, Detail: fmt.Sprintf("file of %d bytes exceeds the %d byte limit", size, c.maxSize)}
+	}
+	return c.policy.CheckSize(size)
+}
+
+// ResumableUpload serves a tus-style chunked upload protocol over
+// file.Service, so large files upload without being held in memory and
+// resume after a dropped connection:
+//
+//	POST  <base>                 Upload-Length, Upload-Metadata: filename <base64>
+//	                             -> 201, Location: <base>/<id>
+//	HEAD  <base>/<id>            -> Upload-Offset, Upload-Length
+//	PATCH <base>/<id>            Upload-Offset, application/offset+octet-stream body,
+//	                             optional Upload-Checksum: sha256 <base64>
+//	POST  <base>/<id>/finalize   optional Upload-Checksum of the whole file
+//	                             -> UploadedFile
+//	POST  <base>/<id>/commit     uri=<destination folder>, see Staging.Commit
+//	DELETE <base>/<id>           discards the upload, see Staging.Discard
+//
+// Chunks are staged under uploads/<id>/ next to the upload state and
+// assembled into uploads/<id>/<filename> on finalize. A chunk interrupted
+// mid-transfer is dropped, so the client resumes from the HEAD offset. The
+// upload policy applies to the name and size on create and to the sniffed
+// content of the first chunk. The quota is checked on create against the
+// declared Upload-Length, which chunks can never exceed, so PATCH does not
+// check it again. A finalized upload accepts no more chunks.
+type ResumableUpload struct {
+	fs *file.Service
+	*uploadConfig
+}
+
+// uploadState is persisted as uploads/<id>/.upload.json. Updated is set on
+// every save and ages the upload for the sweeper. Finalized is set once the
+// file is assembled and may be committed.
+type uploadState struct {
+	Name      string    `json:"name"`
+	Length    int64     `json:"length"`
+	Offset    int64     `json:"offset"`
+	Chunks    []int64   `json:"chunks,omitempty"`
+	Owner     string    `json:"owner,omitempty"`
+	Created   time.Time `json:"created"`
+	Updated   time.Time `json:"updated,omitempty"`
+	Finalized bool      `json:"finalized,omitempty"`
+}
+
+func NewResumableUpload(fs *file.Service, options ...UploadOption) *ResumableUpload {
+	result := &ResumableUpload{fs: fs, uploadConfig: newUploadConfig(options...)}
+	if result.staging == nil {
+		result.staging = NewStaging(fs)
+	}
+	return result
+}
+
+// Handler serves the protocol for requests under baseURI.
+func (u *ResumableUpload) Handler(baseURI string) http.HandlerFunc {
+	baseURI = strings.TrimSuffix(baseURI, "/")
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Tus-Resumable", tusVersion)
+		rest := strings.Trim(strings.TrimPrefix(r.URL.Path, baseURI), "/")
+		id, action, _ := strings.Cut(rest, "/")
+		switch {
+		case rest == "" && r.Method == http.MethodOptions:
+			header := w.Header()
+			header.Set("Tus-Version", tusVersion)
+			header.Set("Tus-Extension", "creation,checksum,termination")
+			header.Set("Tus-Checksum-Algorithm", "md5,sha1,sha256")
+			header.Set("Tus-Max-Size", strconv.FormatInt(u.maxSize, 10))
+			w.WriteHeader(http.StatusNoContent)
+		case rest == "" && r.Method == http.MethodPost:
+			u.create(w, r)
+		case !validUploadID(id) || (action != "" && action != "finalize" && action != "commit"):
+			writeUploadProblem(w, r, http.StatusNotFound, "Upload not found", "")
+		case action == "" && r.Method == http.MethodHead:
+			u.status(w, r, id)
+		case action == "" && r.Method == http.MethodPatch:
+			u.patch(w, r, id)
+		case action == "" && r.Method == http.MethodDelete:
+			u.staging.discard(w, r, id)
+		case action == "finalize" && r.Method == http.MethodPost:
+			u.finalize(w, r, id)
+		case action == "commit" && r.Method == http.MethodPost:
+			u.staging.commit(w, r, id)
+		default:
+			writeUploadProblem(w, r, http.StatusMethodNotAllowed, "Method not allowed", r.Method+" is not supported here")
+		}
+	}
+}
+
+func (u *ResumableUpload) create(w http.ResponseWriter, r *http.Request) {
+	length, err := strconv.ParseInt(r.Header.Get("Upload-Length"), 10, 64)
+	if err != nil || length < 0 {
+		writeUploadProblem(w, r, http.StatusBadRequest, "Invalid upload", "Upload-Length must be a non-negative integer")
+		return
+	}
+	metadata, err := parseUploadMetadata(r.Header.Get("Upload-Metadata"))
+	if err != nil {
+		writeUploadProblem(w, r, http.StatusBadRequest, "Invalid upload", err.Error())
+		return
+	}
+	name, err := u.policy.CheckName(metadata["filename"])
+	if err == nil {
+		err = u.checkSize(length)
+	}
+	if err != nil {
+		writeUploadError(w, r, err)
+		return
+	}
+	id := newUUID()
+	state := &uploadState{Name: name, Length: length, Owner: uploadOwner(r.Context()), Created: time.Now().UTC()}
+	if err := u.staging.reserve(r.Context(), u.policy, id, state); err != nil {
+		var policyErr *PolicyError
+		if errors.As(err, &policyErr) {
+			writeUploadError(w, r, err)
+			return
+		}
+		log.Printf("upload create failed: %v", err)
+		writeUploadProblem(w, r, http.StatusInternalServerError, "Unable to create upload", "")
+		return
+	}
+	w.Header().Set("Location", strings.TrimSuffix(r.URL.Path, "/")+"/"+id)
+	w.Header().Set("Upload-Offset", "0")
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (u *ResumableUpload) status(w http.ResponseWriter, r *http.Request, id string) {
+	state, ok := u.load(w, r, id)
+	if !ok {
+		return
+	}
+	header := w.Header()
+	header.Set("Cache-Control", "no-store")
+	header.Set("Upload-Offset", strconv.FormatInt(state.Offset, 10))
+	header.Set("Upload-Length", strconv.FormatInt(state.Length, 10))
+	w.WriteHeader(http.StatusOK)
+}
+
+func (u *ResumableUpload) patch(w http.ResponseWriter, r *http.Request, id string) {
+	if r.Header.Get("Content-Type") != offsetContentType {
+		writeUploadProblem(w, r, http.StatusUnsupportedMediaType, "Invalid chunk", "Content-Type must be "+offsetContentType)
+		return
+	}
+	offset, err := strconv.ParseInt(r.Header.Get("Upload-Offset"), 10, 64)
+	if err != nil {
+		writeUploadProblem(w, r, http.StatusBadRequest, "Invalid chunk", "Upload-Offset must be an integer")
+		return
+	}
+	checksum, err := newChecksum(r.Header.Get("Upload-Checksum"))
+	if err != nil {
+		writeUploadProblem(w, r, http.StatusBadRequest, "Invalid chunk", err.Error())
+		return
+	}
+	unlock := u.staging.lock(id)
+	defer unlock()
+	state, ok := u.load(w, r, id)
+	if !ok {
+		return
+	}
+	if state.Finalized {
+		writeUploadError(w, r, &PolicyError{Status: http.StatusConflict, Code: UploadFinalized, Detail: fmt.Sprintf("upload %s is already finalized", id)})
+		return
+	}
+	if offset != state.Offset {
+		writeUploadProblem(w, r, http.StatusConflict, "Offset mismatch", fmt.Sprintf("upload is at offset %d, got %d", state.Offset, offset))
+		return
+	}
+	limit := state.Length - state.Offset
+	if u.maxChunk > 0 && limit > u.maxChunk {
+		limit = u.maxChunk
+	}
+	body := bufio.NewReaderSize(io.LimitReader(r.Body, limit+1), sniffLength)
+	if state.Offset == 0 {
+		head, _ := body.Peek(sniffLength)
+		if err := u.policy.CheckContent(head); err != nil {
+			writeUploadError(w, r, err)
+			return
+		}
+	}
+	counter := &countingReader{reader: body}
+	var reader io.Reader = counter
+	if checksum != nil {
+		reader = io.TeeReader(counter, checksum.hash)
+	}
+	chunk := u.chunkURI(id, len(state.Chunks))
+	if err := u.fs.UploadStream(r.Context(), chunk, reader); err != nil {
+		_ = u.fs.Delete(r.Context(), chunk)
+		log.Printf("upload chunk failed: %v", err)
+		writeUploadProblem(w, r, http.StatusInternalServerError, "Unable to store chunk", "")
+		return
+	}
+	if counter.count > limit {
+		_ = u.fs.Delete(r.Context(), chunk)
+		writeUploadProblem(w, r, http.StatusRequestEntityTooLarge, "Chunk too large", fmt.Sprintf("chunk exceeds %d bytes", limit))
+		return
+	}
+	if checksum != nil && !checksum.matches() {
+		_ = u.fs.Delete(r.Context(), chunk)
+		writeUploadProblem(w, r, statusChecksumMismatch, "Checksum mismatch", "chunk does not match Upload-Checksum")
+		return
+	}
+	state.Offset += counter.count
+	state.Chunks = append(state.Chunks, counter.count)
+	if err := u.save(r, id, state); err != nil {
+		_ = u.fs.Delete(r.Context(), chunk)
+		log.Printf("upload state failed: %v", err)
+		writeUploadProblem(w, r, http.StatusInternalServerError, "Unable to store chunk", "")
+		return
+	}
+	w.Header().Set("Upload-Offset", strconv.FormatInt(state.Offset, 10))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (u *ResumableUpload) finalize(w http.ResponseWriter, r *http.Request, id string) {
+	checksum, err := newChecksum(r.Header.Get("Upload-Checksum"))
+	if err != nil {
+		writeUploadProblem(w, r, http.StatusBadRequest, "Invalid upload", err.Error())
+		return
+	}
+	unlock := u.staging.lock(id)
+	defer unlock()
+	state, ok := u.load(w, r, id)
+	if !ok {
+		return
+	}
+	if state.Finalized {
+		writeUploadError(w, r, &PolicyError{Status: http.StatusConflict, Code: UploadFinalized, Detail: fmt.Sprintf("upload %s is already finalized", id)})
+		return
+	}
+	if state.Offset != state.Length {
+		writeUploadProblem(w, r, http.StatusConflict, "Upload incomplete", fmt.Sprintf("received %d of %d bytes", state.Offset, state.Length))
+		return
+	}
+	stagingFolder := path.Join(uploadsFolder, id)
+	target := path.Join(stagingFolder, state.Name)
+	reader := &chunkReader{request: r, upload: u, id: id, count: len(state.Chunks)}
+	defer reader.Close()
+	var source io.Reader = reader
+	if checksum != nil {
+		source = io.TeeReader(reader, checksum.hash)
+	}
+	if err := u.fs.UploadStream(r.Context(), target, source); err != nil {
+		log.Printf("upload finalize failed: %v", err)
+		writeUploadProblem(w, r, http.StatusInternalServerError, "Unable to store file", "")
+		return
+	}
+	if checksum != nil && !checksum.matches() {
+		_ = u.fs.Delete(r.Context(), target)
+		writeUploadProblem(w, r, statusChecksumMismatch, "Checksum mismatch", "file does not match Upload-Checksum")
+		return
+	}
+	state.Finalized = true
+	state.Chunks = nil
+	if err := u.save(r, id, state); err != nil {
+		log.Printf("upload state failed: %v", err)
+		writeUploadProblem(w, r, http.StatusInternalServerError, "Unable to store file", "")
+		return
+	}
+	_ = u.fs.Delete(r.Context(), path.Join(stagingFolder, uploadChunksFolder))
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(UploadedFile{Name: state.Name, Size: state.Length, URI: target, StagingFolder: stagingFolder})
+}
+
+// load reads the upload state after checking the caller owns it, or writes a
+// 404 or 403 problem.
+func (u *ResumableUpload) load(w http.ResponseWriter, r *http.Request, id string) (*uploadState, bool) {
+	stateURI := path.Join(uploadsFolder, id, uploadStateFile)
+	if exists, _ := u.fs.Exists(r.Context(), stateURI); !exists {
+		writeUploadProblem(w, r, http.StatusNotFound, "Upload not found", "")
+		return nil, false
+	}
+	data, err := u.fs.Download(r.Context(), stateURI)
+	state := &uploadState{}
+	if err == nil {
+		err = json.Unmarshal(data, state)
+	}
+	if err != nil {
+		log.Printf("upload state %s: %v", id, err)
+		writeUploadProblem(w, r, http.StatusInternalServerError, "Unable to read upload", "")
+		return nil, false
+	}
+	if state.Owner != "" && state.Owner != uploadOwner(r.Context()) {
+		writeUploadError(w, r, &PolicyError{Status: http.StatusForbidden, Code: UploadNotOwned, Detail: fmt.Sprintf("upload %s belongs to another user", id)})
+		return nil, false
+	}
+	return state, true
+}
+
+func (u *ResumableUpload) save(r *http.Request, id string, state *uploadState) error {
+	return u.staging.save(r.Context(), id, state)
+}
+
+func (u *ResumableUpload) chunkURI(id string, index int) string {
+	return path.Join(uploadsFolder, id, uploadChunksFolder, fmt.Sprintf("%06d", index))
+}
+
+// chunkReader streams the stored chunks of an upload in order.
+type chunkReader struct {
+	request *http.Request
+	upload  *ResumableUpload
+	id      string
+	count   int
+	index   int
+	current io.ReadCloser
+}
+
+func (c *chunkReader) Read(data []byte) (int, error) {
+	for {
+		if c.current == nil {
+			if c.index >= c.count {
+				return 0, io.EOF
+			}
+			content, err := c.upload.fs.Open(c.request.Context(), c.upload.chunkURI(c.id, c.index))
+			if err != nil {
+				return 0, err
+			}
+			c.current = content
+			c.index++
+		}
+		n, err := c.current.Read(data)
+		if err == io.EOF {
+			c.current.Close()
+			c.current = nil
+			if n == 0 {
+				continue
+			}
+			err = nil
+		}
+		return n, err
+	}
+}
+
+func (c *chunkReader) Close() error {
+	if c.current == nil {
+		return nil
+	}
+	return c.current.Close()
+}
+
+type countingReader struct {
+	reader io.Reader
+	count  int64
+}
+
+func (c *countingReader) Read(data []byte) (int, error) {
+	n, err := c.reader.Read(data)
+	c.count += int64(n)
+	return n, err
+}
+
+// checksum verifies an Upload-Checksum header: "<algorithm> <base64 digest>".
+type checksum struct {
+	hash     hash.Hash
+	expected []byte
+}
+
+func newChecksum(header string) (*checksum, error) {
+	if header == "" {
+		return nil, nil
+	}
+	algorithm, encoded, ok := strings.Cut(strings.TrimSpace(header), " ")
+	if !ok {
+		return nil, fmt.Errorf("Upload-Checksum must be \"<algorithm> <base64 digest>\"")
+	}
+	expected, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("invalid Upload-Checksum digest: %w", err)
+	}
+	result := &checksum{expected: expected}
+	switch strings.ToLower(algorithm) {
+	case "md5":
+		result.hash = md5.New()
+	case "sha1":
+		result.hash = sha1.New()
+	case "sha256":
+		result.hash = sha256.New()
+	default:
+		return nil, fmt.Errorf("unsupported checksum algorithm %q", algorithm)
+	}
+	return result, nil
+}
+
+func (c *checksum) matches() bool {
+	return string(c.hash.Sum(nil)) == string(c.expected)
+}
+
+// parseUploadMetadata decodes "key <base64>,key2 <base64>" pairs.
+func parseUploadMetadata(header string) (map[string]string, error) {
+	result := map[string]string{}
+	for _, pair := range strings.Split(header, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		key, encoded, _ := strings.Cut(pair, " ")
+		value, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+		if err != nil {
+			return nil, fmt.Errorf("invalid Upload-Metadata value of %s: %w", key, err)
+		}
+		result[key] = string(value)
+	}
+	return result, nil
+}
+
+func validUploadID(id string) bool {
+	if len(id) != 36 {
+		return false
+	}
+	for _, c := range id {
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c == '-') {
+			return false
+		}
+	}
+	return true
+}
+
+func writeUploadProblem(w http.ResponseWriter, r *http.Request, status int, title, detail string) {
+	writeProblem(w, &Problem{Type: "about:blank", Title: title, Status: status, Detail: detail, Instance: r.URL.Path})
+}
@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/viant/forge/backend/service/file"
+	"github.com/viant/forge/backend/service/identity"
+)
+
+func TestResumableUpload_ChunkedLifecycle(t *testing.T) {
+	root := t.TempDir()
+	handler := NewResumableUpload(file.New(root), WithMaxUploadSize(64), WithMaxChunkSize(6)).Handler("/v1/api/uploads")
+	serve := func(method, target string, headers map[string]string, body string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(method, target, strings.NewReader(body))
+		for key, value := range headers {
+			request.Header.Set(key, value)
+		}
+		recorder := httptest.NewRecorder()
+		handler(recorder, request)
+		return recorder
+	}
+	encode := func(value string) string {
+		return base64.StdEncoding.EncodeToString([]byte(value))
+	}
+	digest := func(value string) string {
+		sum := sha256.Sum256([]byte(value))
+		return "sha256 " + base64.StdEncoding.EncodeToString(sum[:])
+	}
+	patch := func(location string, offset int, chunk string, checksum string) *httptest.ResponseRecorder {
+		headers := map[string]string{"Content-Type": offsetContentType, "Upload-Offset": strconv.Itoa(offset)}
+		if checksum != "" {
+			headers["Upload-Checksum"] = checksum
+		}
+		return serve(http.MethodPatch, location, headers, chunk)
+	}
+
+	if recorder := serve(http.MethodPost, "/v1/api/uploads", map[string]string{"Upload-Length": "65", "Upload-Metadata": "filename " + encode("big.log")}, ""); recorder.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected 413 over the size limit, got %d", recorder.Code)
+	}
+	recorder := serve(http.MethodPost, "/v1/api/uploads", map[string]string{"Upload-Length": "10", "Upload-Metadata": "filename " + encode("../notes.txt")}, "")
+	location := recorder.Header().Get("Location")
+	if recorder.Code != http.StatusCreated || !strings.HasPrefix(location, "/v1/api/uploads/") {
+		t.Fatalf("expected 201 with location, got %d %q", recorder.Code, location)
+	}
+
+	if recorder := patch(location, 0, "hello ", digest("hello ")); recorder.Code != http.StatusNoContent || recorder.Header().Get("Upload-Offset") != "6" {
+		t.Fatalf("expected first chunk accepted, got %d %q: %s", recorder.Code, recorder.Header().Get("Upload-Offset"), recorder.Body.String())
+	}
+	testCases := []struct {
+		description string
+		offset      int
+		chunk       string
+		checksum    string
+		status      int
+	}{
+		{description: "stale offset", offset: 0, chunk: "again", status: http.StatusConflict},
+		{description: "corrupted chunk", offset: 6, chunk: "wxyz", checksum: digest("worl"), status: statusChecksumMismatch},
+		{description: "past declared length", offset: 6, chunk: "world!", status: http.StatusRequestEntityTooLarge},
+	}
+	for _, testCase := range testCases {
+		if recorder := patch(location, testCase.offset, testCase.chunk, testCase.checksum); recorder.Code != testCase.status {
+			t.Fatalf("%s: expected %d, got %d", testCase.description, testCase.status, recorder.Code)
+		}
+	}
+	if recorder := serve(http.MethodHead, location, nil, ""); recorder.Code != http.StatusOK || recorder.Header().Get("Upload-Offset") != "6" || recorder.Header().Get("Upload-Length") != "10" {
+		t.Fatalf("expected offset 6 of 10 after rejected chunks, got %d %v", recorder.Code, recorder.Header())
+	}
+	if recorder := serve(http.MethodPost, location+"/finalize", nil, ""); recorder.Code != http.StatusConflict {
+		t.Fatalf("expected incomplete upload to be rejected, got %d", recorder.Code)
+	}
+	if recorder := patch(location, 6, "worl", digest("worl")); recorder.Code != http.StatusNoContent || recorder.Header().Get("Upload-Offset") != "10" {
+		t.Fatalf("expected second chunk accepted, got %d %q", recorder.Code, recorder.Header().Get("Upload-Offset"))
+	}
+	if recorder := serve(http.MethodPost, location+"/finalize", map[string]string{"Upload-Checksum": digest("something else")}, ""); recorder.Code != statusChecksumMismatch {
+		t.Fatalf("expected whole-file checksum mismatch, got %d", recorder.Code)
+	}
+
+	recorder = serve(http.MethodPost, location+"/finalize", map[string]string{"Upload-Checksum": digest("hello worl")}, "")
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected finalize to succeed, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	var uploaded UploadedFile
+	if err := json.Unmarshal(recorder.Body.Bytes(), &uploaded); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	id := strings.TrimPrefix(location, "/v1/api/uploads/")
+	if uploaded.Name != "notes.txt" || uploaded.Size != 10 || uploaded.StagingFolder != "uploads/"+id || uploaded.URI != "uploads/"+id+"/notes.txt" {
+		t.Fatalf("unexpected upload %+v", uploaded)
+	}
+	data, err := os.ReadFile(filepath.Join(root, "uploads", id, "notes.txt"))
+	if err != nil || string(data) != "hello worl" {
+		t.Fatalf("expected assembled file, got %q (%v)", data, err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "uploads", id, uploadChunksFolder)); !os.IsNotExist(err) {
+		t.Fatalf("expected chunks to be removed, got %v", err)
+	}
+	if recorder := serve(http.MethodHead, location, nil, ""); recorder.Code != http.StatusOK || recorder.Header().Get("Upload-Offset") != "10" {
+		t.Fatalf("expected finalized upload status, got %d %v", recorder.Code, recorder.Header())
+	}
+	if recorder := serve(http.MethodPost, location+"/finalize", nil, ""); recorder.Code != http.StatusConflict {
+		t.Fatalf("expected second finalize to be rejected, got %d", recorder.Code)
+	}
+	recorder = patch(location, 10, "!", "")
+	var problem Problem
+	if recorder.Code != http.StatusConflict || json.Unmarshal(recorder.Body.Bytes(), &problem) != nil || problem.Code != UploadFinalized {
+		t.Fatalf("expected chunk after finalize to be rejected with %s, got %d: %s", UploadFinalized, recorder.Code, recorder.Body.String())
+	}
+}
+
+func TestResumableUpload_RestrictsUploadsToOwner(t *testing.T) {
+	upload := NewResumableUpload(file.New(t.TempDir()))
+	handler := upload.Handler("/v1/api/uploads")
+	serve := func(method, target, subject string, headers map[string]string, body string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(method, target, strings.NewReader(body))
+		for key, value := range headers {
+			request.Header.Set(key, value)
+		}
+		request = request.WithContext(identity.WithContext(request.Context(), &identity.Identity{Subject: subject}))
+		recorder := httptest.NewRecorder()
+		handler(recorder, request)
+		return recorder
+	}
+	metadata := "filename " + base64.StdEncoding.EncodeToString([]byte("notes.txt"))
+	location := serve(http.MethodPost, "/v1/api/uploads", "alice", map[string]string{"Upload-Length": "5", "Upload-Metadata": metadata}, "").Header().Get("Location")
+	chunk := map[string]string{"Content-Type": offsetContentType, "Upload-Offset": "0"}
+
+	testCases := []struct {
+		description string
+		method      string
+		target      string
+		headers     map[string]string
+		body        string
+	}{
+		{description: "status", method: http.MethodHead, target: location},
+		{description: "patch", method: http.MethodPatch, target: location, headers: chunk, body: "hello"},
+		{description: "finalize", method: http.MethodPost, target: location + "/finalize"},
+	}
+	for _, testCase := range testCases {
+		if recorder := serve(testCase.method, testCase.target, "bob", testCase.headers, testCase.body); recorder.Code != http.StatusForbidden {
+			t.Fatalf("%s: expected 403 for another caller, got %d", testCase.description, recorder.Code)
+		}
+	}
+	if recorder := serve(http.MethodPatch, location, "alice", chunk, "hello"); recorder.Code != http.StatusNoContent {
+		t.Fatalf("expected owner chunk accepted, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	if recorder := serve(http.MethodPost, location+"/finalize", "alice", nil, ""); recorder.Code != http.StatusOK {
+		t.Fatalf("expected owner finalize to succeed, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	if len(upload.staging.locks) != 0 {
+		t.Fatalf("expected upload locks to be released, got %d", len(upload.staging.locks))
+	}
+}
@@ -0,0 +1,347 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"mime"
+	"net/http"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/viant/forge/backend/service/file"
+	"github.com/viant/forge/backend/service/identity"
+	"github.com/viant/forge/backend/service/meta"
+)
+
+// DefaultPrefix is where NewRouter mounts the endpoints when Config.Prefix is
+// empty.
+const DefaultPrefix = "/v1/api"
+
+// RequestIDHeader carries the request id in both directions.
+const RequestIDHeader = "X-Request-ID"
+
+// Config describes the endpoints NewRouter mounts. Metadata endpoints need
+// Loader, file endpoints need Files and /live needs LiveReload; the others
+// are left out when their dependency is nil.
+type Config struct {
+	// Prefix the endpoints are mounted under, DefaultPrefix when empty.
+	Prefix string
+	// Loader and BaseURL serve /window/, /navigation, /prefetch and /diff/.
+	Loader  *meta.Service
+	BaseURL string
+	// Files serves /files/list, /files/download, /upload and the resumable
+	// /uploads protocol with commit and discard.
+	Files *file.Service
+	// Staging, built over Files, commits, discards and sweeps /uploads;
+	// the host calls its Start. Without it uploads use a NewStaging with
+	// the default TTL and are never swept.
+	Staging *Staging
+	// LiveReload serves /live; the host starts and closes it.
+	LiveReload *LiveReload
+	// Identity resolves the caller from the Authorization header.
+	Identity *identity.Parser
+	// AllowedOrigins enables CORS for the listed origins, "*" for any
+	// origin without credentials.
+	AllowedOrigins []string
+	// PrefetchParallelism bounds /prefetch window loads.
+	PrefetchParallelism int
+	// Logger receives access and panic logs, log.Default() when nil.
+	Logger *log.Logger
+	// Uploads configures /upload and /uploads, e.g. WithUploadPolicy.
+	Uploads []UploadOption
+	// Middleware wraps the endpoints inside the built-in chain, after the
+	// caller identity is resolved; the first entry is the outermost.
+	Middleware []func(http.Handler) http.Handler
+}
+
+// ErrorResponse is the error envelope of router endpoints, the failure
+// counterpart of the {status: "ok", data} responses.
+type ErrorResponse struct {
+	Status    string   `json:"status"`
+	Error     *Problem `json:"error"`
+	RequestID string   `json:"requestId,omitempty"`
+}
+
+// NewRouter mounts all Forge endpoints under cfg.Prefix behind a shared
+// chain: request ids, access logging, error envelopes, panic recovery, CORS
+// and caller identity, in that order from the outside in.
+func NewRouter(cfg Config) http.Handler {
+	prefix := "/" + strings.Trim(cfg.Prefix, "/")
+	if cfg.Prefix == "" {
+		prefix = DefaultPrefix
+	}
+	prefix = strings.TrimSuffix(prefix, "/")
+
+	mux := http.NewServeMux()
+	if cfg.Loader != nil {
+		mux.Handle(prefix+"/window/", WindowHandler(cfg.Loader, cfg.BaseURL, prefix+"/window/"))
+		mux.Handle(prefix+"/navigation", NavigationHandler(cfg.Loader, cfg.BaseURL))
+		mux.Handle(prefix+"/prefetch", PrefetchHandler(cfg.Loader, cfg.BaseURL, cfg.PrefetchParallelism))
+		mux.Handle(prefix+"/diff/", WindowDiffHandler(cfg.Loader, cfg.BaseURL, prefix+"/diff/"))
+	}
+	if cfg.LiveReload != nil {
+		mux.Handle(prefix+"/live", cfg.LiveReload.Handler())
+	}
+	if cfg.Files != nil {
+		browser := NewFileBrowser(cfg.Files)
+		mux.HandleFunc(prefix+"/files/list", browser.ListHandler)
+		mux.HandleFunc(prefix+"/files/download", browser.DownloadHandler)
+		// Both upload endpoints share one Staging, so they share quota locks.
+		staging := cfg.Staging
+		if staging == nil {
+			staging = NewStaging(cfg.Files)
+		}
+		options := append([]UploadOption{WithStaging(staging)}, cfg.Uploads...)
+		mux.Handle(prefix+"/upload", UploadHandler(cfg.Files, options...))
+		uploads := NewResumableUpload(cfg.Files, options...).Handler(prefix + "/uploads")
+		mux.Handle(prefix+"/uploads", uploads)
+		mux.Handle(prefix+"/uploads/", uploads)
+	}
+
+	var handler http.Handler = mux
+	for i := len(cfg.Middleware) - 1; i >= 0; i-- {
+		if cfg.Middleware[i] != nil {
+			handler = cfg.Middleware[i](handler)
+		}
+	}
+	if cfg.Identity != nil {
+		handler = IdentityHandler(cfg.Identity, handler)
+	}
+	if len(cfg.AllowedOrigins) > 0 {
+		handler = CORSHandler(cfg.AllowedOrigins, handler)
+	}
+	handler = RecoveryHandler(cfg.Logger, handler)
+	handler = ErrorEnvelopeHandler(handler)
+	handler = LoggingHandler(cfg.Logger, handler)
+	return RequestIDHandler(handler)
+}
+
+type requestIDKey struct{}
+
+// RequestIDFromContext returns the id RequestIDHandler assigned.
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// RequestIDHandler keeps a well-formed incoming X-Request-ID or assigns a
+// new one, and echoes it in the response and the request context.
+func RequestIDHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if !validRequestID(id) {
+			id = newUUID()
+		}
+		w.Header().Set(RequestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestIDKey{}, id)))
+	})
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for _, c := range id {
+		if c <= ' ' || c > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// LoggingHandler writes one access log line per request with the status,
+// size, duration and request id.
+func LoggingHandler(logger *log.Logger, next http.Handler) http.Handler {
+	if logger == nil {
+		logger = log.Default()
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(recorder, r)
+		if recorder.status == 0 {
+			recorder.status = http.StatusOK
+		}
+		logger.Printf("%s %s %d %dB %s id=%s", r.Method, r.URL.RequestURI(), recorder.status, recorder.size, time.Since(started).Round(time.Microsecond), RequestIDFromContext(r.Context()))
+	})
+}
+
+// RecoveryHandler turns a panicking handler into a 500 problem and logs the
+// stack. http.ErrAbortHandler is re-raised so the server aborts as intended.
+func RecoveryHandler(logger *log.Logger, next http.Handler) http.Handler {
+	if logger == nil {
+		logger = log.Default()
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+			if recovered == http.ErrAbortHandler {
+				panic(recovered)
+			}
+			logger.Printf("panic serving %s id=%s: %v\n%s", r.URL.Path, RequestIDFromContext(r.Context()), recovered, debug.Stack())
+			writeProblem(w, &Problem{Type: "about:blank", Title: "Internal server error", Status: http.StatusInternalServerError, Instance: r.URL.Path})
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
+
+// CORSHandler allows cross-origin requests from origins and answers
+// preflight requests itself. Listed origins are echoed and may send
+// credentials; "*" admits any other origin with a literal wildcard and no
+// credentials. Requests from other origins pass through without CORS
+// headers, so browsers reject them.
+func CORSHandler(origins []string, next http.Handler) http.Handler {
+	allowed := map[string]bool{}
+	for _, origin := range origins {
+		allowed[strings.TrimSuffix(strings.TrimSpace(origin), "/")] = true
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		header := w.Header()
+		header.Add("Vary", "Origin")
+		switch {
+		case origin == "":
+			next.ServeHTTP(w, r)
+			return
+		case allowed[origin]:
+			header.Set("Access-Control-Allow-Origin", origin)
+			header.Set("Access-Control-Allow-Credentials", "true")
+		case allowed["*"]:
+			header.Set("Access-Control-Allow-Origin", "*")
+		default:
+			next.ServeHTTP(w, r)
+			return
+		}
+		header.Set("Access-Control-Expose-Headers", "ETag, Content-Disposition, Location, Upload-Offset, Upload-Length, Tus-Resumable, "+RequestIDHeader)
+		if r.Method != http.MethodOptions || r.Header.Get("Access-Control-Request-Method") == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		header.Add("Vary", "Access-Control-Request-Method, Access-Control-Request-Headers")
+		header.Set("Access-Control-Allow-Methods", "GET, HEAD, POST, PUT, PATCH, DELETE, OPTIONS")
+		if requested := r.Header.Get("Access-Control-Request-Headers"); requested != "" {
+			header.Set("Access-Control-Allow-Headers", requested)
+		}
+		header.Set("Access-Control-Max-Age", "600")
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// ErrorEnvelopeHandler rewrites every 4xx/5xx response of next, whether a
+// problem document or a plain http.Error text, into an ErrorResponse.
+// Successful responses, including streams, pass through untouched.
+func ErrorEnvelopeHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writer := &envelopeWriter{ResponseWriter: w}
+		next.ServeHTTP(writer, r)
+		if writer.status < http.StatusBadRequest {
+			return
+		}
+		problem := writer.problem(r)
+		header := w.Header()
+		header.Del("Content-Length")
+		header.Set("Content-Type", "application/json")
+		header.Set("X-Content-Type-Options", "nosniff")
+		w.WriteHeader(problem.Status)
+		_ = json.NewEncoder(w).Encode(ErrorResponse{Status: "error", Error: problem, RequestID: RequestIDFromContext(r.Context())})
+	})
+}
+
+// envelopeWriter buffers error bodies for ErrorEnvelopeHandler.
+type envelopeWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (w *envelopeWriter) WriteHeader(status int) {
+	if w.status != 0 {
+		return
+	}
+	w.status = status
+	if status < http.StatusBadRequest {
+		w.ResponseWriter.WriteHeader(status)
+	}
+}
+
+func (w *envelopeWriter) Write(data []byte) (int, error) {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.status >= http.StatusBadRequest {
+		return w.body.Write(data)
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *envelopeWriter) Flush() {
+	if w.status >= http.StatusBadRequest {
+		return
+	}
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (w *envelopeWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// problem decodes the buffered problem document or wraps the plain text
+// error body as its detail.
+func (w *envelopeWriter) problem(r *http.Request) *Problem {
+	mediaType, _, _ := mime.ParseMediaType(w.Header().Get("Content-Type"))
+	if strings.HasSuffix(mediaType, "json") {
+		problem := &Problem{}
+		if err := json.Unmarshal(w.body.Bytes(), problem); err == nil && problem.Title != "" {
+			problem.Status = w.status
+			return problem
+		}
+	}
+	return &Problem{
+		Type:     "about:blank",
+		Title:    http.StatusText(w.status),
+		Status:   w.status,
+		Detail:   strings.TrimSpace(w.body.String()),
+		Instance: r.URL.Path,
+	}
+}
+
+// statusRecorder captures the status and size LoggingHandler reports.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	size   int
+}
+
+func (w *statusRecorder) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusRecorder) Write(data []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(data)
+	w.size += n
+	return n, err
+}
+
+func (w *statusRecorder) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (w *statusRecorder) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
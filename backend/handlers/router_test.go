@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/viant/afs"
+	"github.com/viant/forge/backend/service/file"
+	"github.com/viant/forge/backend/service/meta"
+)
+
+func TestNewRouter_MountsEndpointsWithErrorEnvelope(t *testing.T) {
+	root := t.TempDir()
+	base := filepath.Join(root, "window")
+	mustWriteHandlerMetaFile(t, filepath.Join(base, "order", "main.yaml"), "namespace: Order\n")
+	baseURL := "file://" + filepath.ToSlash(base)
+	panicking := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Query().Get("panic") != "" {
+				panic("boom")
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+	router := NewRouter(Config{
+		Prefix:         "/forge/",
+		Loader:         meta.New(afs.New(), baseURL),
+		BaseURL:        baseURL,
+		Files:          file.New(root),
+		AllowedOrigins: []string{"https://app.example.com"},
+		Logger:         log.New(io.Discard, "", 0),
+		Middleware:     []func(http.Handler) http.Handler{panicking},
+	})
+
+	request := httptest.NewRequest(http.MethodGet, "/forge/window/order?platform=web", nil)
+	request.Header.Set(RequestIDHeader, "req-1")
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	if recorder.Code != http.StatusOK || recorder.Header().Get(RequestIDHeader) != "req-1" {
+		t.Fatalf("expected 200 with request id, got %d %q: %s", recorder.Code, recorder.Header().Get(RequestIDHeader), recorder.Body.String())
+	}
+	var window WindowResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &window); err != nil || window.Status != "ok" || window.Data.Namespace != "Order" {
+		t.Fatalf("unexpected window response %s (%v)", recorder.Body.String(), err)
+	}
+
+	testCases := []struct {
+		description string
+		path        string
+		status      int
+		title       string
+		detail      string
+	}{
+		{description: "load problem", path: "/forge/window/missing", status: http.StatusNotFound, title: "Metadata not found"},
+		{description: "plain text error", path: "/forge/files/download", status: http.StatusBadRequest, title: "Bad Request", detail: "Missing path parameter"},
+		{description: "unknown route", path: "/forge/unknown", status: http.StatusNotFound, title: "Not Found", detail: "404 page not found"},
+		{description: "panic", path: "/forge/navigation?panic=1", status: http.StatusInternalServerError, title: "Internal server error"},
+	}
+	for _, testCase := range testCases {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, testCase.path, nil))
+		if recorder.Code != testCase.status {
+			t.Fatalf("%s: expected status %d, got %d", testCase.description, testCase.status, recorder.Code)
+		}
+		if got := recorder.Header().Get("Content-Type"); got != "application/json" {
+			t.Fatalf("%s: unexpected content type %q", testCase.description, got)
+		}
+		var response ErrorResponse
+		if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+			t.Fatalf("%s: invalid envelope: %v", testCase.description, err)
+		}
+		if response.Status != "error" || response.Error == nil || response.Error.Status != testCase.status || response.Error.Title != testCase.title {
+			t.Fatalf("%s: unexpected envelope %s", testCase.description, recorder.Body.String())
+		}
+		if testCase.detail != "" && response.Error.Detail != testCase.detail {
+			t.Fatalf("%s: expected detail %q, got %q", testCase.description, testCase.detail, response.Error.Detail)
+		}
+		if response.RequestID == "" || response.RequestID != recorder.Header().Get(RequestIDHeader) {
+			t.Fatalf("%s: expected request id in envelope and header, got %q", testCase.description, response.RequestID)
+		}
+	}
+}
+
+func TestCORSHandler_AnswersPreflight(t *testing.T) {
+	handler := CORSHandler([]string{"https://app.example.com/"}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+
+	request := httptest.NewRequest(http.MethodOptions, "/v1/api/navigation", nil)
+	request.Header.Set("Origin", "https://app.example.com")
+	request.Header.Set("Access-Control-Request-Method", http.MethodGet)
+	request.Header.Set("Access-Control-Request-Headers", "authorization")
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	if recorder.Code != http.StatusNoContent || recorder.Header().Get("Access-Control-Allow-Origin") != "https://app.example.com" || recorder.Header().Get("Access-Control-Allow-Headers") != "authorization" {
+		t.Fatalf("unexpected preflight response %d %v", recorder.Code, recorder.Header())
+	}
+
+	request = httptest.NewRequest(http.MethodGet, "/v1/api/navigation", nil)
+	request.Header.Set("Origin", "https://other.example.com")
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	if recorder.Code != http.StatusTeapot || recorder.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Fatalf("expected disallowed origin to pass through without CORS headers, got %d %v", recorder.Code, recorder.Header())
+	}
+}
+
+func TestCORSHandler_WildcardOmitsCredentials(t *testing.T) {
+	handler := CORSHandler([]string{"*", "https://app.example.com"}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	testCases := []struct {
+		description string
+		origin      string
+		allowOrigin string
+		credentials string
+	}{
+		{description: "listed origin", origin: "https://app.example.com", allowOrigin: "https://app.example.com", credentials: "true"},
+		{description: "any other origin", origin: "https://other.example.com", allowOrigin: "*"},
+	}
+	for _, testCase := range testCases {
+		request := httptest.NewRequest(http.MethodGet, "/v1/api/navigation", nil)
+		request.Header.Set("Origin", testCase.origin)
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		if got := recorder.Header().Get("Access-Control-Allow-Origin"); got != testCase.allowOrigin {
+			t.Fatalf("%s: expected allow origin %q, got %q", testCase.description, testCase.allowOrigin, got)
+		}
+		if got := recorder.Header().Get("Access-Control-Allow-Credentials"); got != testCase.credentials {
+			t.Fatalf("%s: expected allow credentials %q, got %q", testCase.description, testCase.credentials, got)
+		}
+	}
+}
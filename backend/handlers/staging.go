@@ -0,0 +1,350 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/viant/afs/url"
+	"github.com/viant/forge/backend/service/file"
+)
+
+// quotaLockPrefix keys the lock of a caller's quota in the lock set shared
+// with upload ids.
+const quotaLockPrefix = "quota:"
+
+const (
+	// DefaultStagingTTL is how long staged uploads are kept uncommitted.
+	DefaultStagingTTL = 24 * time.Hour
+	// DefaultSweepInterval is how often Staging.Start sweeps.
+	DefaultSweepInterval = time.Hour
+)
+
+// StagingOption configures Staging.
+type StagingOption func(*Staging)
+
+// WithStagingTTL sets how long staged uploads are kept after their last
+// activity before they are swept.
+func WithStagingTTL(ttl time.Duration) StagingOption {
+	return func(s *Staging) {
+		s.ttl = ttl
+	}
+}
+
+// WithSweepInterval sets how often Start sweeps.
+func WithSweepInterval(interval time.Duration) StagingOption {
+	return func(s *Staging) {
+		s.interval = interval
+	}
+}
+
+// Staging manages the lifecycle of uploads staged under uploads/<id>/ by
+// UploadHandler and ResumableUpload: Commit moves the file to its final
+// destination, typically the chat Upload.Uri, Discard drops it, and Sweep
+// deletes staging folders idle for longer than the TTL so abandoned
+// attachments do not accumulate. The same Staging should back the
+// ResumableUpload, see WithStaging, so sweeps skip uploads in progress.
+type Staging struct {
+	fs       *file.Service
+	ttl      time.Duration
+	interval time.Duration
+	now      func() time.Time
+
+	mu    sync.Mutex
+	locks map[string]*uploadLock
+}
+
+// uploadLock is the mutex of one upload, dropped once nothing holds or waits
+// for it.
+type uploadLock struct {
+	sync.Mutex
+	refs int
+}
+
+func NewStaging(fs *file.Service, options ...StagingOption) *Staging {
+	result := &Staging{fs: fs, ttl: DefaultStagingTTL, interval: DefaultSweepInterval, now: time.Now, locks: map[string]*uploadLock{}}
+	for _, option := range options {
+		if option != nil {
+			option(result)
+		}
+	}
+	return result
+}
+
+// Commit moves the staged file of upload id into the destination folder,
+// relative to the file service root, and removes the staging folder. Only
+// the uploading caller may commit its upload.
+func (s *Staging) Commit(ctx context.Context, id, destination string) (*UploadedFile, error) {
+	destination = strings.Trim(destination, "/")
+	if url.Scheme(destination, "") != "" || strings.Contains(destination, "..") {
+		return nil, &PolicyError{Status: http.StatusBadRequest, Code: UploadInvalidDestination, Detail: fmt.Sprintf("invalid destination %q", destination)}
+	}
+	unlock := s.lock(id)
+	defer unlock()
+	state, err := s.staged(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !state.Finalized {
+		return nil, &PolicyError{Status: http.StatusConflict, Code: UploadIncomplete, Detail: fmt.Sprintf("upload is not finalized, received %d of %d bytes", state.Offset, state.Length)}
+	}
+	stagingFolder := path.Join(uploadsFolder, id)
+	target := path.Join(destination, state.Name)
+	if exists, _ := s.fs.Exists(ctx, target); exists {
+		return nil, &PolicyError{Status: http.StatusConflict, Code: UploadDestinationExists, Detail: fmt.Sprintf("%s already exists", target)}
+	}
+	if err := s.fs.Move(ctx, path.Join(stagingFolder, state.Name), target); err != nil {
+		return nil, fmt.Errorf("failed to commit upload %s: %w", id, err)
+	}
+	if err := s.fs.Delete(ctx, stagingFolder); err != nil {
+		log.Printf("upload %s committed, staging cleanup failed: %v", id, err)
+	}
+	return &UploadedFile{Name: state.Name, Size: state.Length, URI: target}, nil
+}
+
+// Discard deletes the staging folder of upload id, complete or not.
+func (s *Staging) Discard(ctx context.Context, id string) error {
+	unlock := s.lock(id)
+	defer unlock()
+	if _, err := s.staged(ctx, id); err != nil {
+		return err
+	}
+	return s.fs.Delete(ctx, path.Join(uploadsFolder, id))
+}
+
+// Sweep deletes staging folders idle for longer than the TTL and returns how
+// many it removed. Idle time runs from the last update of the upload state,
+// or the folder modification time for folders without one; uploads a
+// request is working on are skipped.
+func (s *Staging) Sweep(ctx context.Context) (int, error) {
+	if exists, _ := s.fs.Exists(ctx, uploadsFolder); !exists {
+		return 0, nil
+	}
+	folders, err := s.fs.List(ctx, file.WithURI(uploadsFolder), file.WithOnlyFolder(true))
+	if err != nil {
+		return 0, err
+	}
+	deadline := s.now().Add(-s.ttl)
+	removed := 0
+	var errs []error
+	for _, folder := range folders {
+		for _, child := range folder.ChildNodes {
+			id := path.Base(child.URI)
+			unlock, ok := s.tryLock(id)
+			if !ok {
+				continue
+			}
+			stagingFolder := path.Join(uploadsFolder, id)
+			active, err := s.lastActive(ctx, stagingFolder)
+			if err == nil && active.Before(deadline) {
+				if err = s.fs.Delete(ctx, stagingFolder); err != nil {
+					errs = append(errs, err)
+				} else {
+					removed++
+				}
+			}
+			unlock()
+		}
+	}
+	return removed, errors.Join(errs...)
+}
+
+// Start sweeps now and then every interval until ctx is done.
+func (s *Staging) Start(ctx context.Context) {
+	sweep := func() {
+		if removed, err := s.Sweep(ctx); err != nil {
+			log.Printf("upload staging sweep failed: %v", err)
+		} else if removed > 0 {
+			log.Printf("upload staging sweep removed %d expired uploads", removed)
+		}
+	}
+	go func() {
+		sweep()
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				sweep()
+			}
+		}
+	}()
+}
+
+// staged returns the state of upload id after checking the caller owns it.
+// Staging folders without a state hold a single file uploaded before states
+// were recorded.
+func (s *Staging) staged(ctx context.Context, id string) (*uploadState, error) {
+	stagingFolder := path.Join(uploadsFolder, id)
+	notFound := &PolicyError{Status: http.StatusNotFound, Code: UploadNotFound, Detail: fmt.Sprintf("upload %s not found", id)}
+	if !validUploadID(id) {
+		return nil, notFound
+	}
+	if exists, _ := s.fs.Exists(ctx, stagingFolder); !exists {
+		return nil, notFound
+	}
+	state := &uploadState{}
+	if data, err := s.fs.Download(ctx, path.Join(stagingFolder, uploadStateFile)); err == nil {
+		if err := json.Unmarshal(data, state); err != nil {
+			return nil, fmt.Errorf("invalid upload state %s: %w", id, err)
+		}
+	} else {
+		listed, err := s.fs.List(ctx, file.WithURI(stagingFolder))
+		if err != nil {
+			return nil, err
+		}
+		var files []file.File
+		for _, folder := range listed {
+			for _, child := range folder.ChildNodes {
+				if !child.IsFolder {
+					files = append(files, child)
+				}
+			}
+		}
+		if len(files) != 1 {
+			return nil, notFound
+		}
+		info, err := s.fs.Stat(ctx, path.Join(stagingFolder, files[0].Name))
+		if err != nil {
+			return nil, err
+		}
+		state = &uploadState{Name: files[0].Name, Length: info.Size(), Offset: info.Size(), Finalized: true, Created: info.ModTime()}
+	}
+	if state.Owner != "" && state.Owner != uploadOwner(ctx) {
+		return nil, &PolicyError{Status: http.StatusForbidden, Code: UploadNotOwned, Detail: fmt.Sprintf("upload %s belongs to another user", id)}
+	}
+	return state, nil
+}
+
+// lastActive returns when the upload in stagingFolder was last written.
+func (s *Staging) lastActive(ctx context.Context, stagingFolder string) (time.Time, error) {
+	if data, err := s.fs.Download(ctx, path.Join(stagingFolder, uploadStateFile)); err == nil {
+		state := &uploadState{}
+		if json.Unmarshal(data, state) == nil {
+			if !state.Updated.IsZero() {
+				return state.Updated, nil
+			}
+			if !state.Created.IsZero() {
+				return state.Created, nil
+			}
+		}
+	}
+	info, err := s.fs.Stat(ctx, stagingFolder)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return info.ModTime(), nil
+}
+
+// lock serializes the requests and sweeps of one upload. The returned func
+// unlocks it and forgets the lock when nothing else waits for it.
+func (s *Staging) lock(id string) func() {
+	s.mu.Lock()
+	mutex, ok := s.locks[id]
+	if !ok {
+		mutex = &uploadLock{}
+		s.locks[id] = mutex
+	}
+	mutex.refs++
+	s.mu.Unlock()
+	mutex.Lock()
+	return s.unlocker(id, mutex)
+}
+
+// reserve stores state as upload id once the quota of the caller of ctx
+// allows state.Length more bytes. The caller's quota lock is held from the
+// check until the state is stored, so concurrent uploads of one caller cannot
+// both claim the same remaining quota.
+func (s *Staging) reserve(ctx context.Context, policy *UploadPolicy, id string, state *uploadState) error {
+	unlock := s.lock(quotaLockPrefix + state.Owner)
+	defer unlock()
+	if err := policy.CheckQuota(ctx, s.fs, state.Length); err != nil {
+		return err
+	}
+	return s.write(ctx, id, state)
+}
+
+// save stores the state of upload id under its owner's quota lock, so
+// quota checks never read a partly written state.
+func (s *Staging) save(ctx context.Context, id string, state *uploadState) error {
+	unlock := s.lock(quotaLockPrefix + state.Owner)
+	defer unlock()
+	return s.write(ctx, id, state)
+}
+
+// write stores the state of upload id, stamping Updated.
+func (s *Staging) write(ctx context.Context, id string, state *uploadState) error {
+	state.Updated = s.now().UTC()
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	return s.fs.Upload(ctx, path.Join(uploadsFolder, id, uploadStateFile), data)
+}
+
+// tryLock locks upload id unless a request holds or waits for it.
+func (s *Staging) tryLock(id string) (func(), bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, busy := s.locks[id]; busy {
+		return nil, false
+	}
+	mutex := &uploadLock{refs: 1}
+	mutex.Lock()
+	s.locks[id] = mutex
+	return s.unlocker(id, mutex), true
+}
+
+func (s *Staging) unlocker(id string, mutex *uploadLock) func() {
+	return func() {
+		mutex.Unlock()
+		s.mu.Lock()
+		if mutex.refs--; mutex.refs == 0 {
+			delete(s.locks, id)
+		}
+		s.mu.Unlock()
+	}
+}
+
+// commit serves POST <base>/<id>/commit with the destination in the uri
+// query parameter or a {"uri": ...} body.
+func (s *Staging) commit(w http.ResponseWriter, r *http.Request, id string) {
+	destination := r.URL.Query().Get("uri")
+	if destination == "" && r.ContentLength != 0 {
+		body := struct {
+			URI string `json:"uri"`
+		}{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeUploadProblem(w, r, http.StatusBadRequest, "Invalid commit request", err.Error())
+			return
+		}
+		destination = body.URI
+	}
+	committed, err := s.Commit(r.Context(), id, destination)
+	if err != nil {
+		log.Printf("upload commit %s: %v", id, err)
+		writeUploadError(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(committed)
+}
+
+// discard serves DELETE <base>/<id>, the tus termination request.
+func (s *Staging) discard(w http.ResponseWriter, r *http.Request, id string) {
+	if err := s.Discard(r.Context(), id); err != nil {
+		log.Printf("upload discard %s: %v", id, err)
+		writeUploadError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
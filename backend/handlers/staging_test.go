@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/viant/forge/backend/service/file"
+	"github.com/viant/forge/backend/service/identity"
+)
+
+func TestStaging_CommitDiscardAndSweep(t *testing.T) {
+	root := t.TempDir()
+	fs := file.New(root)
+	stage := func(id, name string, created time.Time, owner string) {
+		mustWriteHandlerMetaFile(t, filepath.Join(root, "uploads", id, name), "content of "+name)
+		state, _ := json.Marshal(&uploadState{Name: name, Length: int64(len("content of " + name)), Offset: int64(len("content of " + name)), Owner: owner, Created: created, Finalized: true})
+		mustWriteHandlerMetaFile(t, filepath.Join(root, "uploads", id, uploadStateFile), string(state))
+	}
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	committed := "11111111-1111-4111-8111-111111111111"
+	discarded := "22222222-2222-4222-8222-222222222222"
+	expired := "33333333-3333-4333-8333-333333333333"
+	stage(committed, "report.pdf", now, "alice")
+	stage(discarded, "draft.txt", now, "")
+	stage(expired, "old.txt", now.Add(-25*time.Hour), "")
+	handler := NewResumableUpload(fs).Handler("/v1/api/uploads")
+	serve := func(method, target, subject string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(method, target, nil)
+		if subject != "" {
+			request = request.WithContext(identity.WithContext(request.Context(), &identity.Identity{Subject: subject}))
+		}
+		recorder := httptest.NewRecorder()
+		handler(recorder, request)
+		return recorder
+	}
+
+	testCases := []struct {
+		description string
+		target      string
+		subject     string
+		status      int
+		code        string
+	}{
+		{description: "other owner", target: committed + "/commit?uri=chat/attachments", subject: "bob", status: http.StatusForbidden, code: UploadNotOwned},
+		{description: "escaping destination", target: committed + "/commit?uri=../secrets", subject: "alice", status: http.StatusBadRequest, code: UploadInvalidDestination},
+		{description: "missing upload", target: "44444444-4444-4444-8444-444444444444/commit?uri=chat", status: http.StatusNotFound, code: UploadNotFound},
+	}
+	for _, testCase := range testCases {
+		recorder := serve(http.MethodPost, "/v1/api/uploads/"+testCase.target, testCase.subject)
+		var problem Problem
+		if recorder.Code != testCase.status || json.Unmarshal(recorder.Body.Bytes(), &problem) != nil || problem.Code != testCase.code {
+			t.Fatalf("%s: expected %d %s, got %d: %s", testCase.description, testCase.status, testCase.code, recorder.Code, recorder.Body.String())
+		}
+	}
+
+	recorder := serve(http.MethodPost, "/v1/api/uploads/"+committed+"/commit?uri=chat/attachments/", "alice")
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected commit to succeed, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	var uploaded UploadedFile
+	if err := json.Unmarshal(recorder.Body.Bytes(), &uploaded); err != nil || uploaded.URI != "chat/attachments/report.pdf" || uploaded.StagingFolder != "" {
+		t.Fatalf("unexpected commit response %+v (%v)", uploaded, err)
+	}
+	if data, err := os.ReadFile(filepath.Join(root, "chat", "attachments", "report.pdf")); err != nil || string(data) != "content of report.pdf" {
+		t.Fatalf("expected committed file, got %q (%v)", data, err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "uploads", committed)); !os.IsNotExist(err) {
+		t.Fatalf("expected staging folder to be removed after commit, got %v", err)
+	}
+
+	if recorder := serve(http.MethodDelete, "/v1/api/uploads/"+discarded, ""); recorder.Code != http.StatusNoContent {
+		t.Fatalf("expected discard to succeed, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	if _, err := os.Stat(filepath.Join(root, "uploads", discarded)); !os.IsNotExist(err) {
+		t.Fatalf("expected discarded staging folder to be removed, got %v", err)
+	}
+
+	stage(discarded, "fresh.txt", now.Add(-time.Hour), "")
+	staging := NewStaging(fs, WithStagingTTL(24*time.Hour))
+	staging.now = func() time.Time { return now }
+	removed, err := staging.Sweep(context.Background())
+	if err != nil || removed != 1 {
+		t.Fatalf("expected one expired upload swept, got %d (%v)", removed, err)
+	}
+	entries, _ := os.ReadDir(filepath.Join(root, "uploads"))
+	if len(entries) != 1 || !strings.HasPrefix(entries[0].Name(), "2222") {
+		t.Fatalf("expected only the fresh upload to remain, got %v", entries)
+	}
+}
+
+func TestStaging_SweepSkipsActiveUploads(t *testing.T) {
+	root := t.TempDir()
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	stage := func(id string, created, updated time.Time) {
+		state, _ := json.Marshal(&uploadState{Name: "big.bin", Length: 10, Offset: 4, Created: created, Updated: updated})
+		mustWriteHandlerMetaFile(t, filepath.Join(root, "uploads", id, uploadStateFile), string(state))
+	}
+	resuming := "11111111-1111-4111-8111-111111111111"
+	locked := "22222222-2222-4222-8222-222222222222"
+	abandoned := "33333333-3333-4333-8333-333333333333"
+	stage(resuming, now.Add(-48*time.Hour), now.Add(-time.Hour))
+	stage(locked, now.Add(-48*time.Hour), now.Add(-48*time.Hour))
+	stage(abandoned, now.Add(-48*time.Hour), now.Add(-25*time.Hour))
+	staging := NewStaging(file.New(root), WithStagingTTL(24*time.Hour))
+	staging.now = func() time.Time { return now }
+
+	unlock := staging.lock(locked)
+	removed, err := staging.Sweep(context.Background())
+	unlock()
+	if err != nil || removed != 1 {
+		t.Fatalf("expected only the abandoned upload swept, got %d (%v)", removed, err)
+	}
+	for _, id := range []string{resuming, locked} {
+		if _, err := os.Stat(filepath.Join(root, "uploads", id)); err != nil {
+			t.Fatalf("expected upload %s to be kept: %v", id, err)
+		}
+	}
+	if len(staging.locks) != 0 {
+		t.Fatalf("expected sweep locks to be released, got %d", len(staging.locks))
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/viant/forge/backend/service/identity"
 	"github.com/viant/forge/backend/service/meta"
 )
 
@@ -12,15 +13,23 @@ func targetContextFromRequest(r *http.Request) *meta.TargetContext {
 		return nil
 	}
 	query := r.URL.Query()
+	// An explicit locale parameter wins over the browser's preferences.
+	locale := strings.TrimSpace(query.Get("locale"))
+	if locale == "" {
+		locale = strings.TrimSpace(r.Header.Get("Accept-Language"))
+	}
 	return &meta.TargetContext{
 		Platform:     strings.TrimSpace(query.Get("platform")),
 		FormFactor:   strings.TrimSpace(query.Get("formFactor")),
 		Surface:      strings.TrimSpace(query.Get("surface")),
-		Capabilities: capabilityValuesFromQuery(query["capabilities"]),
+		Capabilities: listValuesFromQuery(query["capabilities"]),
+		AppVersion:   strings.TrimSpace(query.Get("appVersion")),
+		Locale:       locale,
+		Identity:     identity.FromContext(r.Context()),
 	}
 }
 
-func capabilityValuesFromQuery(values []string) []string {
+func listValuesFromQuery(values []string) []string {
 	if len(values) == 0 {
 		return nil
 	}
@@ -21,3 +21,23 @@ func TestTargetContextFromRequest_NormalizesRepeatedAndCommaCapabilities(t *test
 		t.Fatalf("expected capabilities %v, got %v", expectedCapabilities, target.Capabilities)
 	}
 }
+
+func TestTargetContextFromRequest_AppVersion(t *testing.T) {
+	request := httptest.NewRequest("GET", "/meta/order?platform=ios&appVersion=%202.4.1%20", nil)
+	if target := targetContextFromRequest(request); target.AppVersion != "2.4.1" {
+		t.Fatalf("expected trimmed app version, got %q", target.AppVersion)
+	}
+}
+
+func TestTargetContextFromRequest_Locale(t *testing.T) {
+	request := httptest.NewRequest("GET", "/meta/order", nil)
+	request.Header.Set("Accept-Language", "pt-BR,pt;q=0.9")
+	if target := targetContextFromRequest(request); target.Locale != "pt-BR,pt;q=0.9" {
+		t.Fatalf("expected Accept-Language locale, got %q", target.Locale)
+	}
+	request = httptest.NewRequest("GET", "/meta/order?locale=fr", nil)
+	request.Header.Set("Accept-Language", "pt-BR")
+	if target := targetContextFromRequest(request); target.Locale != "fr" {
+		t.Fatalf("expected locale query parameter to win, got %q", target.Locale)
+	}
+}
@@ -1,13 +1,17 @@
 package handlers
 
 import (
+	"bytes"
 	"crypto/rand"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"log"
 	"net/http"
 	"path"
+	"time"
 
 	"github.com/viant/forge/backend/service/file"
 )
@@ -27,15 +31,35 @@ func newUUID() string {
 	return hexStr[0:8] + "-" + hexStr[8:12] + "-" + hexStr[12:16] + "-" + hexStr[16:20] + "-" + hexStr[20:32]
 }
 
+// multipartOverhead is the allowance for multipart framing and form fields
+// on top of the file size limit.
+const multipartOverhead = 1 << 20
+
 // UploadHandler handles multipart file uploads and stores them via file.Service in a staging folder.
-// Staging path format: .tmp/uploads/<uuid>/<original-name>
+// Staging path format: uploads/<uuid>/<sanitized-name>
+// The upload policy is enforced before the file is stored; violations are
+// reported as problems with a code, e.g. upload_too_large.
 // Returns: { name, size, uri, stagingFolder }
-func UploadHandler(fs *file.Service) http.HandlerFunc {
+func UploadHandler(fs *file.Service, options ...UploadOption) http.HandlerFunc {
+	config := newUploadConfig(options...)
+	if config.staging == nil {
+		config.staging = NewStaging(fs)
+	}
 	return func(w http.ResponseWriter, r *http.Request) {
+		limit := config.sizeLimit()
+		if limit > 0 {
+			r.Body = http.MaxBytesReader(w, r.Body, limit+multipartOverhead)
+		}
 		if err := r.ParseMultipartForm(32 << 20); err != nil { // 32MB memory buffer
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				writeUploadError(w, r, &PolicyError{Status: http.StatusRequestEntityTooLarge, Code: UploadTooLarge, Detail: fmt.Sprintf("upload exceeds the %d byte limit", limit)})
+				return
+			}
 			http.Error(w, "invalid multipart form", http.StatusBadRequest)
 			return
 		}
+		defer r.MultipartForm.RemoveAll()
 
 		fileHeader := r.MultipartForm.File["file"]
 		if len(fileHeader) == 0 {
@@ -44,6 +68,15 @@ func UploadHandler(fs *file.Service) http.HandlerFunc {
 		}
 
 		fh := fileHeader[0]
+		name, err := config.policy.CheckName(fh.Filename)
+		if err == nil {
+			err = config.checkSize(fh.Size)
+		}
+		if err != nil {
+			writeUploadError(w, r, err)
+			return
+		}
+
 		src, err := fh.Open()
 		if err != nil {
 			http.Error(w, "unable to open upload", http.StatusInternalServerError)
@@ -51,28 +84,50 @@ func UploadHandler(fs *file.Service) http.HandlerFunc {
 		}
 		defer src.Close()
 
-		payload, err := io.ReadAll(src)
-		if err != nil {
+		head := make([]byte, sniffLength)
+		n, err := io.ReadFull(src, head)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
 			http.Error(w, "unable to read upload", http.StatusInternalServerError)
 			return
 		}
+		head = head[:n]
+		if err := config.policy.CheckContent(head); err != nil {
+			writeUploadError(w, r, err)
+			return
+		}
 
-		name := fh.Filename
 		uuid := newUUID()
-		stagingFolder := "uploads/" + uuid
+		stagingFolder := path.Join(uploadsFolder, uuid)
 		target := path.Join(stagingFolder, name)
-		if err := fs.Upload(r.Context(), target, payload); err != nil {
+		// Record the upload like ResumableUpload does, for quotas and the
+		// staging lifecycle; the state reserves the quota before the file
+		// is stored.
+		state := &uploadState{Name: name, Length: fh.Size, Owner: uploadOwner(r.Context()), Created: time.Now().UTC()}
+		if err := config.staging.reserve(r.Context(), config.policy, uuid, state); err != nil {
+			var policyErr *PolicyError
+			if !errors.As(err, &policyErr) {
+				log.Printf("upload state failed: %v", err)
+			}
+			writeUploadError(w, r, err)
+			return
+		}
+		if err := fs.UploadStream(r.Context(), target, io.MultiReader(bytes.NewReader(head), src)); err != nil {
 			log.Printf("upload failed: %v", err)
+			_ = fs.Delete(r.Context(), stagingFolder)
 			http.Error(w, "unable to store file", http.StatusInternalServerError)
 			return
 		}
+		state.Offset, state.Finalized = fh.Size, true
+		if err := config.staging.save(r.Context(), uuid, state); err != nil {
+			log.Printf("upload state failed: %v", err)
+		}
 
 		// Build response
-		resp := map[string]interface{}{
-			"name":          name,
-			"size":          fh.Size,
-			"uri":           target,
-			"stagingFolder": stagingFolder,
+		resp := UploadedFile{
+			Name:          name,
+			Size:          fh.Size,
+			URI:           target,
+			StagingFolder: stagingFolder,
 		}
 
 		w.Header().Set("Content-Type", "application/json")
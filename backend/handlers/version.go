@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// metadataCacheControl lets clients keep metadata but revalidate it with
+// If-None-Match on every use, which costs a 304 when nothing changed.
+const metadataCacheControl = "no-cache"
+
+// ContentVersion hashes resolved metadata, so any change to the window, its
+// imports, target or locale resolution, or its action code yields a new
+// version.
+func ContentVersion(data interface{}) (string, error) {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(encoded)
+	return hex.EncodeToString(sum[:16]), nil
+}
+
+// writeVersioned sets the validator and caching headers for version and
+// writes response, or 304 Not Modified when the client already has it.
+func writeVersioned(w http.ResponseWriter, r *http.Request, version string, response interface{}) {
+	etag := `"` + version + `"`
+	header := w.Header()
+	header.Set("ETag", etag)
+	header.Set("Cache-Control", metadataCacheControl)
+	// Locale and caller identity both shape the resolved metadata.
+	header.Add("Vary", "Accept-Language, Authorization")
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	header.Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// etagMatches applies the weak comparison If-None-Match requires.
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/viant/afs"
+	"github.com/viant/forge/backend/service/meta"
+)
+
+func TestWindowHandler_ConditionalGet(t *testing.T) {
+	root := t.TempDir()
+	base := filepath.Join(root, "window")
+	mustWriteHandlerMetaFile(t, filepath.Join(base, "order", "shared", "main.yaml"), "namespace: Order\nview:\n  content:\n    table: $import(table.yaml)\n")
+	mustWriteHandlerMetaFile(t, filepath.Join(base, "order", "shared", "table.yaml"), "width: 100%\n")
+	mustWriteHandlerMetaFile(t, filepath.Join(base, "order", "shared", "main.js"), "(() => ({}))()")
+	baseURL := "file://" + filepath.ToSlash(base)
+	handler := WindowHandler(meta.New(afs.New(), baseURL), baseURL, "/v1/api/window/")
+	get := func(ifNoneMatch string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodGet, "/v1/api/window/order?platform=web", nil)
+		if ifNoneMatch != "" {
+			request.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		recorder := httptest.NewRecorder()
+		handler(recorder, request)
+		return recorder
+	}
+
+	first := get("")
+	etag := first.Header().Get("ETag")
+	var response WindowResponse
+	if err := json.Unmarshal(first.Body.Bytes(), &response); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if first.Code != http.StatusOK || etag != `"`+response.Version+`"` || response.Version == "" {
+		t.Fatalf("expected versioned 200, got %d etag=%q version=%q", first.Code, etag, response.Version)
+	}
+	if first.Header().Get("Cache-Control") != metadataCacheControl {
+		t.Fatalf("expected Cache-Control %q, got %q", metadataCacheControl, first.Header().Get("Cache-Control"))
+	}
+	if recorder := get(`W/"other", ` + etag); recorder.Code != http.StatusNotModified || recorder.Body.Len() != 0 {
+		t.Fatalf("expected empty 304, got %d %q", recorder.Code, recorder.Body.String())
+	}
+
+	mustWriteHandlerMetaFile(t, filepath.Join(base, "order", "shared", "table.yaml"), "width: 50%\n")
+	afterImport := get(etag)
+	if afterImport.Code != http.StatusOK || afterImport.Header().Get("ETag") == etag {
+		t.Fatalf("expected import change to produce a new version, got %d", afterImport.Code)
+	}
+	etag = afterImport.Header().Get("ETag")
+	mustWriteHandlerMetaFile(t, filepath.Join(base, "order", "shared", "main.js"), "(() => ({ changed: true }))()")
+	if recorder := get(etag); recorder.Code != http.StatusOK || recorder.Header().Get("ETag") == etag {
+		t.Fatalf("expected asset change to produce a new version, got %d", recorder.Code)
+	}
+}
+
+func TestEtagMatches(t *testing.T) {
+	testCases := []struct {
+		header   string
+		expected bool
+	}{
+		{header: `"v1"`, expected: true},
+		{header: `W/"v1"`, expected: true},
+		{header: `"v0", "v1"`, expected: true},
+		{header: `*`, expected: true},
+		{header: `"v2"`, expected: false},
+		{header: ``, expected: false},
+	}
+	for _, testCase := range testCases {
+		if actual := etagMatches(testCase.header, `"v1"`); actual != testCase.expected {
+			t.Fatalf("etagMatches(%q) expected %v, got %v", testCase.header, testCase.expected, actual)
+		}
+	}
+}
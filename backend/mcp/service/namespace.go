@@ -6,9 +6,8 @@ import (
 	"encoding/hex"
 	"fmt"
 	"net/http"
-	"strings"
 
-	"github.com/golang-jwt/jwt/v5"
+	"github.com/viant/forge/backend/service/identity"
 	"github.com/viant/mcp-protocol/authorization"
 )
 
@@ -52,19 +51,14 @@ func (s *NamespaceService) NamespaceFromRequest(r *http.Request) string {
 }
 
 func normalizeBearer(v string) string {
-	v = strings.TrimSpace(v)
-	if strings.HasPrefix(strings.ToLower(v), "bearer ") {
-		return strings.TrimSpace(v[len("bearer "):])
-	}
-	return v
+	return identity.NormalizeBearer(v)
 }
 
 func namespaceFromTokenString(tokenString string, fallback string) string {
 	if tokenString == "" {
 		return fallback
 	}
-	var claimMap jwt.MapClaims
-	if _, _, err := new(jwt.Parser).ParseUnverified(tokenString, &claimMap); err == nil {
+	if claimMap, err := identity.UnverifiedClaims(tokenString); err == nil {
 		if email, _ := claimMap["email"].(string); email != "" {
 			return email
 		}
@@ -8,8 +8,11 @@ import (
 	"github.com/viant/afs/file"
 	"github.com/viant/afs/storage"
 	"github.com/viant/afs/url"
+	"io"
+	"os"
 	"path"
 	"strings"
+	"time"
 )
 
 type (
@@ -29,6 +32,16 @@ type (
 		URI        string `json:"uri"`
 		ChildNodes []File `json:"childNodes"`
 	}
+
+	// Content is an open file stream with the metadata conditional and
+	// ranged requests need. The reader is an io.Seeker when the storage
+	// supports it, e.g. for local files.
+	Content struct {
+		io.ReadCloser
+		Name    string
+		Size    int64
+		ModTime time.Time
+	}
 )
 
 // New creates a new Service.
@@ -136,6 +149,24 @@ func (f *Service) Download(ctx context.Context, uri string) ([]byte, error) {
 	return f.service.DownloadWithURL(ctx, URL, f.options...)
 }
 
+// Open streams the file at the specified uri instead of loading it into
+// memory like Download; the caller closes the returned Content.
+func (f *Service) Open(ctx context.Context, uri string) (*Content, error) {
+	URL := f.ensureURL(uri)
+	object, err := f.service.Object(ctx, URL, f.options...)
+	if err != nil {
+		return nil, err
+	}
+	if object.IsDir() {
+		return nil, fmt.Errorf("%q is a folder", uri)
+	}
+	reader, err := f.service.Open(ctx, object, f.options...)
+	if err != nil {
+		return nil, err
+	}
+	return &Content{ReadCloser: reader, Name: path.Base(object.Name()), Size: object.Size(), ModTime: object.ModTime()}, nil
+}
+
 func (f *Service) ensureURL(uri string) string {
 	URL := uri
 	if url.Scheme(uri, "") == "" || url.IsRelative(uri) {
@@ -149,3 +180,26 @@ func (f *Service) Upload(ctx context.Context, uri string, payload []byte) error
 	URL := url.Join(f.root, uri)
 	return f.service.Upload(ctx, URL, file.DefaultFileOsMode, bytes.NewReader(payload), f.options...)
 }
+
+// UploadStream uploads reader to the specified uri without buffering it.
+func (f *Service) UploadStream(ctx context.Context, uri string, reader io.Reader) error {
+	URL := url.Join(f.root, uri)
+	return f.service.Upload(ctx, URL, file.DefaultFileOsMode, reader, f.options...)
+}
+
+// Delete removes the file or folder at the specified uri.
+func (f *Service) Delete(ctx context.Context, uri string) error {
+	URL := f.ensureURL(uri)
+	return f.service.Delete(ctx, URL, f.options...)
+}
+
+// Move moves the file or folder at source to dest.
+func (f *Service) Move(ctx context.Context, source, dest string) error {
+	return f.service.Move(ctx, f.ensureURL(source), f.ensureURL(dest), f.options...)
+}
+
+// Stat returns the size and modification time of the file or folder at the
+// specified uri.
+func (f *Service) Stat(ctx context.Context, uri string) (os.FileInfo, error) {
+	return f.service.Object(ctx, f.ensureURL(uri), f.options...)
+}
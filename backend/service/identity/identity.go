@@ -0,0 +1,226 @@
+package identity
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Identity is the caller metadata is resolved for.
+type Identity struct {
+	Subject string                 `json:"subject,omitempty"`
+	Email   string                 `json:"email,omitempty"`
+	Roles   []string               `json:"roles,omitempty"`
+	Scopes  []string               `json:"scopes,omitempty"`
+	Claims  map[string]interface{} `json:"claims,omitempty"`
+}
+
+// HasRole reports whether the identity carries role.
+func (i *Identity) HasRole(role string) bool {
+	return i != nil && contains(i.Roles, role)
+}
+
+// HasScope reports whether the identity carries scope.
+func (i *Identity) HasScope(scope string) bool {
+	return i != nil && contains(i.Scopes, scope)
+}
+
+// HasClaim reports whether claim equals value or, for list claims, contains
+// it. Nested claims are addressed with dots, e.g. "org.id".
+func (i *Identity) HasClaim(claim, value string) bool {
+	if i == nil {
+		return false
+	}
+	for _, item := range claimStrings(lookupClaim(i.Claims, claim)) {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+// HasClaimSet reports whether claim is present with a non-empty value.
+func (i *Identity) HasClaimSet(claim string) bool {
+	return i != nil && len(claimStrings(lookupClaim(i.Claims, claim))) > 0
+}
+
+// Option configures a Parser.
+type Option func(*Parser)
+
+// WithKeyfunc verifies token signatures with keyfunc. A parser needs either
+// a keyfunc or WithUnverifiedClaims, otherwise every token is rejected.
+func WithKeyfunc(keyfunc jwt.Keyfunc) Option {
+	return func(p *Parser) {
+		p.keyfunc = keyfunc
+	}
+}
+
+// WithUnverifiedClaims accepts tokens without checking their signature when
+// no keyfunc is set. Clients can then claim any role, so this is only safe
+// in development or behind a gateway that verifies tokens.
+func WithUnverifiedClaims() Option {
+	return func(p *Parser) {
+		p.unverified = true
+	}
+}
+
+// WithRoleClaims sets the claims roles are read from, in order. Defaults to
+// "roles", "groups" and "realm_access.roles".
+func WithRoleClaims(claims ...string) Option {
+	return func(p *Parser) {
+		if len(claims) > 0 {
+			p.roleClaims = claims
+		}
+	}
+}
+
+// Parser turns bearer tokens into identities.
+type Parser struct {
+	keyfunc    jwt.Keyfunc
+	unverified bool
+	roleClaims []string
+}
+
+func NewParser(options ...Option) *Parser {
+	result := &Parser{roleClaims: []string{"roles", "groups", "realm_access.roles"}}
+	for _, option := range options {
+		if option != nil {
+			option(result)
+		}
+	}
+	return result
+}
+
+// Parse returns the identity in tokenString. Scopes come from the
+// space-separated "scope" claim or the "scp" list.
+func (p *Parser) Parse(tokenString string) (*Identity, error) {
+	tokenString = NormalizeBearer(tokenString)
+	if tokenString == "" {
+		return nil, fmt.Errorf("empty token")
+	}
+	var claims jwt.MapClaims
+	var err error
+	switch {
+	case p.keyfunc != nil:
+		_, err = jwt.ParseWithClaims(tokenString, &claims, p.keyfunc)
+	case p.unverified:
+		claims, err = UnverifiedClaims(tokenString)
+	default:
+		return nil, fmt.Errorf("no key to verify the token, use WithKeyfunc or WithUnverifiedClaims")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid token: %w", err)
+	}
+	result := &Identity{Claims: map[string]interface{}(claims)}
+	result.Subject, _ = claims["sub"].(string)
+	result.Email, _ = claims["email"].(string)
+	for _, claim := range p.roleClaims {
+		result.Roles = append(result.Roles, claimStrings(lookupClaim(claims, claim))...)
+	}
+	if scope, ok := claims["scope"].(string); ok {
+		result.Scopes = strings.Fields(scope)
+	}
+	result.Scopes = append(result.Scopes, claimStrings(claims["scp"])...)
+	result.Roles, result.Scopes = unique(result.Roles), unique(result.Scopes)
+	return result, nil
+}
+
+// FromRequest parses the Authorization header; a request without one yields
+// a nil identity and no error.
+func (p *Parser) FromRequest(r *http.Request) (*Identity, error) {
+	tokenString := NormalizeBearer(r.Header.Get("Authorization"))
+	if tokenString == "" {
+		return nil, nil
+	}
+	return p.Parse(tokenString)
+}
+
+// NormalizeBearer strips an optional "Bearer " prefix.
+func NormalizeBearer(v string) string {
+	v = strings.TrimSpace(v)
+	if strings.HasPrefix(strings.ToLower(v), "bearer ") {
+		return strings.TrimSpace(v[len("bearer "):])
+	}
+	return v
+}
+
+// UnverifiedClaims decodes the claims of tokenString without checking its
+// signature.
+func UnverifiedClaims(tokenString string) (jwt.MapClaims, error) {
+	var claims jwt.MapClaims
+	if _, _, err := new(jwt.Parser).ParseUnverified(tokenString, &claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+type contextKey struct{}
+
+// WithContext returns a context carrying identity.
+func WithContext(ctx context.Context, identity *Identity) context.Context {
+	return context.WithValue(ctx, contextKey{}, identity)
+}
+
+// FromContext returns the identity stored by WithContext, if any.
+func FromContext(ctx context.Context) *Identity {
+	result, _ := ctx.Value(contextKey{}).(*Identity)
+	return result
+}
+
+func lookupClaim(claims map[string]interface{}, name string) interface{} {
+	var current interface{} = claims
+	for _, part := range strings.Split(name, ".") {
+		mapping, ok := current.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		current = mapping[part]
+	}
+	return current
+}
+
+func claimStrings(value interface{}) []string {
+	switch actual := value.(type) {
+	case nil:
+		return nil
+	case string:
+		return []string{actual}
+	case []string:
+		return actual
+	case []interface{}:
+		result := make([]string, 0, len(actual))
+		for _, item := range actual {
+			result = append(result, fmt.Sprint(item))
+		}
+		return result
+	default:
+		return []string{fmt.Sprint(actual)}
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
+
+func unique(values []string) []string {
+	if len(values) == 0 {
+		return nil
+	}
+	sort.Strings(values)
+	result := values[:1]
+	for _, value := range values[1:] {
+		if value != result[len(result)-1] {
+			result = append(result, value)
+		}
+	}
+	return result
+}
@@ -0,0 +1,55 @@
+package identity
+
+import (
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestParser_ParsesRolesScopesAndClaims(t *testing.T) {
+	secret := []byte("secret")
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub":          "u1",
+		"email":        "ann@example.com",
+		"roles":        []string{"manager"},
+		"realm_access": map[string]interface{}{"roles": []string{"admin", "manager"}},
+		"scope":        "orders.read orders.write",
+		"org":          map[string]interface{}{"id": "acme"},
+	}).SignedString(secret)
+	if err != nil {
+		t.Fatalf("sign token: %v", err)
+	}
+
+	parser := NewParser(WithKeyfunc(func(*jwt.Token) (interface{}, error) { return secret, nil }))
+	request := httptest.NewRequest("GET", "/", nil)
+	request.Header.Set("Authorization", "Bearer "+token)
+	caller, err := parser.FromRequest(request)
+	if err != nil {
+		t.Fatalf("FromRequest() error = %v", err)
+	}
+	if caller.Subject != "u1" || caller.Email != "ann@example.com" {
+		t.Fatalf("unexpected subject/email: %+v", caller)
+	}
+	if !reflect.DeepEqual(caller.Roles, []string{"admin", "manager"}) || !reflect.DeepEqual(caller.Scopes, []string{"orders.read", "orders.write"}) {
+		t.Fatalf("unexpected roles %v scopes %v", caller.Roles, caller.Scopes)
+	}
+	if !caller.HasClaim("org.id", "acme") || caller.HasClaim("org.id", "other") {
+		t.Fatalf("expected nested claim match")
+	}
+
+	wrongKey := NewParser(WithKeyfunc(func(*jwt.Token) (interface{}, error) { return []byte("other"), nil }))
+	if _, err := wrongKey.Parse(token); err == nil {
+		t.Fatalf("expected signature verification to fail")
+	}
+	if _, err := NewParser().Parse(token); err == nil {
+		t.Fatalf("expected a parser without a keyfunc to reject tokens")
+	}
+	if caller, err := NewParser(WithUnverifiedClaims()).Parse(token); err != nil || !caller.HasRole("admin") {
+		t.Fatalf("expected unverified parse to succeed, got %+v err=%v", caller, err)
+	}
+	if caller, err := parser.FromRequest(httptest.NewRequest("GET", "/", nil)); caller != nil || err != nil {
+		t.Fatalf("expected anonymous request, got %+v err=%v", caller, err)
+	}
+}
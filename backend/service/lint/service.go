@@ -0,0 +1,224 @@
+package lint
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/viant/afs"
+	"github.com/viant/afs/url"
+	"github.com/viant/forge/backend/service/meta"
+)
+
+// DefaultTargets are linted when no target is configured.
+var DefaultTargets = []string{"web/desktop", "android/phone", "android/tablet", "ios/phone", "ios/tablet"}
+
+// Finding is a diagnostic for one window, merged across the targets that
+// reported it.
+type Finding struct {
+	meta.Diagnostic
+	Window  string   `json:"window"`
+	Targets []string `json:"targets,omitempty"`
+}
+
+// Report lists the windows linted and what was found.
+type Report struct {
+	Windows  []string  `json:"windows"`
+	Findings []Finding `json:"findings"`
+}
+
+// HasErrors reports whether any finding has error severity.
+func (r *Report) HasErrors() bool {
+	for _, finding := range r.Findings {
+		if finding.Severity == meta.SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// Option configures the linter.
+type Option func(*Service)
+
+// WithTargets sets the target combinations every window is loaded for.
+func WithTargets(targets ...*meta.TargetContext) Option {
+	return func(s *Service) {
+		s.targets = targets
+	}
+}
+
+// WithValidateOptions passes options to meta.Service.ValidateWindow.
+func WithValidateOptions(options ...meta.ValidateOption) Option {
+	return func(s *Service) {
+		s.validateOptions = append(s.validateOptions, options...)
+	}
+}
+
+// Service lints a metadata tree laid out the way meta.Service loads it:
+// window/<key>/shared|web|android/phone/main.yaml or window/<key>.yaml.
+type Service struct {
+	fs              afs.Service
+	windowURL       string
+	loader          *meta.Service
+	targets         []*meta.TargetContext
+	validateOptions []meta.ValidateOption
+}
+
+// New creates a linter for the metadata root containing the window folder.
+func New(fs afs.Service, root string, options ...Option) *Service {
+	windowURL := url.Join(root, "window")
+	result := &Service{fs: fs, windowURL: windowURL, loader: meta.New(fs, windowURL)}
+	for _, option := range options {
+		if option != nil {
+			option(result)
+		}
+	}
+	if len(result.targets) == 0 {
+		for _, value := range DefaultTargets {
+			target, _ := ParseTarget(value)
+			result.targets = append(result.targets, target)
+		}
+	}
+	return result
+}
+
+// ParseTarget parses "platform[/formFactor[/surface]]"; see meta.ParseTarget.
+func ParseTarget(value string) (*meta.TargetContext, error) {
+	return meta.ParseTarget(value)
+}
+
+func targetName(target *meta.TargetContext) string {
+	return target.String()
+}
+
+// Run lints every window under the root for every target.
+func (s *Service) Run(ctx context.Context) (*Report, error) {
+	rootObject, err := s.fs.Object(ctx, s.windowURL)
+	if err != nil {
+		return nil, fmt.Errorf("open window root %s: %w", s.windowURL, err)
+	}
+	rootURL := strings.TrimSuffix(rootObject.URL(), "/")
+	keys, err := s.loader.WindowKeys(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	report := &Report{Windows: keys}
+	for _, key := range keys {
+		report.Findings = append(report.Findings, s.lintWindow(ctx, key)...)
+	}
+	for i := range report.Findings {
+		report.Findings[i].SourcePath = strings.TrimPrefix(strings.TrimPrefix(report.Findings[i].SourcePath, rootURL), "/")
+	}
+	return report, nil
+}
+
+// targetResult is what one target reported for a window.
+type targetResult struct {
+	name        string
+	diagnostics []meta.Diagnostic
+	sources     map[string]bool
+}
+
+// lintWindow loads key for every target and merges the diagnostics.
+func (s *Service) lintWindow(ctx context.Context, key string) []Finding {
+	var results []*targetResult
+	unresolved := 0
+	for _, target := range s.targets {
+		result := &targetResult{name: targetName(target)}
+		results = append(results, result)
+		base, err := s.loader.ResolveWindowKey(ctx, key, target)
+		if err != nil {
+			unresolved++
+			result.diagnostics = append(result.diagnostics, loadDiagnostic("branchUnresolved", meta.SeverityWarning, err))
+			continue
+		}
+		diagnostics, err := s.loader.ValidateWindow(ctx, base+".yaml", target, s.validateOptions...)
+		if err != nil {
+			code := "importBroken"
+			var cycle *meta.ImportCycleError
+			if errors.As(err, &cycle) {
+				code = "importCycle"
+			}
+			result.diagnostics = append(result.diagnostics, loadDiagnostic(code, meta.SeverityError, err))
+			continue
+		}
+		result.diagnostics = diagnostics
+		if graph, err := s.loader.ImportGraph(ctx, base+".yaml", target); err == nil {
+			result.sources = map[string]bool{}
+			for _, source := range graph.Files {
+				result.sources[source] = true
+			}
+		}
+	}
+	if unresolved == len(s.targets) {
+		for _, result := range results {
+			for i := range result.diagnostics {
+				result.diagnostics[i].Severity = meta.SeverityError
+			}
+		}
+	}
+	return mergeFindings(key, results)
+}
+
+// mergeFindings dedups diagnostics across targets. Unused data sources are
+// kept only when every target that loaded the declaring file agrees, since a
+// reference may sit in a node pruned for some targets.
+func mergeFindings(key string, results []*targetResult) []Finding {
+	var findings []Finding
+	index := map[string]int{}
+	for _, result := range results {
+		for _, diagnostic := range result.diagnostics {
+			id := findingID(diagnostic)
+			if position, ok := index[id]; ok {
+				findings[position].Targets = append(findings[position].Targets, result.name)
+				continue
+			}
+			index[id] = len(findings)
+			findings = append(findings, Finding{Diagnostic: diagnostic, Window: key, Targets: []string{result.name}})
+		}
+	}
+	result := findings[:0]
+	for _, finding := range findings {
+		if finding.Code == "dataSourceUnused" && len(finding.Targets) < loadedCount(results, finding.SourcePath) {
+			continue
+		}
+		result = append(result, finding)
+	}
+	return result
+}
+
+// findingID identifies a diagnostic by source position when known, since
+// target pruning shifts sequence indexes in YAML paths.
+func findingID(diagnostic meta.Diagnostic) string {
+	if diagnostic.Line > 0 {
+		return strings.Join([]string{diagnostic.Code, diagnostic.SourcePath, fmt.Sprint(diagnostic.Line, ":", diagnostic.Column)}, "|")
+	}
+	return strings.Join([]string{diagnostic.Code, diagnostic.SourcePath, diagnostic.YAMLPath, diagnostic.Message}, "|")
+}
+
+func loadedCount(results []*targetResult, source string) int {
+	count := 0
+	for _, result := range results {
+		if result.sources[source] {
+			count++
+		}
+	}
+	return count
+}
+
+func loadDiagnostic(code, severity string, err error) meta.Diagnostic {
+	result := meta.Diagnostic{Code: code, Severity: severity, Message: err.Error()}
+	var loadErr *meta.LoadError
+	if errors.As(err, &loadErr) {
+		result.SourcePath = loadErr.URL
+		result.Line, result.Column = loadErr.Line, loadErr.Column
+		if loadErr.Err != nil {
+			result.Message = loadErr.Err.Error()
+		}
+		if len(loadErr.Candidates) > 0 {
+			result.Message += " (tried: " + strings.Join(loadErr.Candidates, ", ") + ")"
+		}
+	}
+	return result
+}
@@ -0,0 +1,103 @@
+package lint
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/viant/afs"
+	"github.com/viant/forge/backend/service/meta"
+)
+
+func TestRun_ReportsFindingsAcrossTargets(t *testing.T) {
+	root := t.TempDir()
+	window := filepath.Join(root, "window")
+	mustWriteLintFile(t, filepath.Join(window, "order", "shared", "main.yaml"), `namespace: order
+dataSource:
+  orders: {}
+  webOnly: {}
+  spare: {}
+view:
+  content:
+    id: main
+    dataSourceRef: orders
+    containers:
+      - id: chart
+        target: web
+        dataSourceRef: webOnly
+      - id: table
+        dataSourceRef: orders
+      - id: table
+        dataSourceRef: orders
+`)
+	mustWriteLintFile(t, filepath.Join(window, "order", "android", "phone", "main.yaml"), "namespace: order\nview:\n  content: $import(missing.yaml)\n")
+	mustWriteLintFile(t, filepath.Join(window, "campaign.yaml"), "namespace: campaign\nview:\n  content:\n    id: main\n")
+	mustWriteLintFile(t, filepath.Join(window, "common", "lookup.yaml"), "id: lookup\n")
+	mustWriteLintFile(t, filepath.Join(window, "webonly", "web", "main.yaml"), "namespace: webonly\nview:\n  content:\n    id: main\n")
+
+	linter := New(afs.New(), root, WithTargets(mustParseTarget(t, "web/desktop"), mustParseTarget(t, "android/phone"), mustParseTarget(t, "ios/phone")))
+	report, err := linter.Run(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := strings.Join(report.Windows, ","); got != "campaign,order,webonly" {
+		t.Fatalf("unexpected windows %q", got)
+	}
+
+	byCode := map[string][]Finding{}
+	for _, finding := range report.Findings {
+		byCode[finding.Code] = append(byCode[finding.Code], finding)
+	}
+	if findings := byCode["importBroken"]; len(findings) != 1 || findings[0].SourcePath != "order/android/phone/main.yaml" || findings[0].Line != 3 || strings.Join(findings[0].Targets, ",") != "android/phone" {
+		t.Fatalf("unexpected importBroken findings %#v", findings)
+	}
+	if findings := byCode["duplicateId"]; len(findings) != 1 || strings.Join(findings[0].Targets, ",") != "web/desktop,ios/phone" {
+		t.Fatalf("unexpected duplicateId findings %#v", findings)
+	}
+	if findings := byCode["dataSourceUnused"]; len(findings) != 1 || findings[0].YAMLPath != "$.dataSource.spare" {
+		t.Fatalf("expected only spare to be unused, got %#v", findings)
+	}
+	if findings := byCode["branchUnresolved"]; len(findings) != 2 || findings[0].Window != "webonly" || findings[0].Severity != meta.SeverityWarning {
+		t.Fatalf("unexpected branchUnresolved findings %#v", findings)
+	}
+	if !report.HasErrors() {
+		t.Fatalf("expected report to have errors")
+	}
+}
+
+func TestWindowKey_StripsBranchFolders(t *testing.T) {
+	testCases := map[string]string{
+		"order/shared":          "order",
+		"order/android/phone":   "order",
+		"order/mobile.phone":    "order",
+		"billing/invoice/web":   "billing/invoice",
+		"shared":                "",
+		"settings/user/profile": "settings/user/profile",
+	}
+	for dir, expected := range testCases {
+		if got := meta.WindowKey(dir); got != expected {
+			t.Fatalf("WindowKey(%q): expected %q, got %q", dir, expected, got)
+		}
+	}
+}
+
+func mustParseTarget(t *testing.T, value string) *meta.TargetContext {
+	t.Helper()
+	target, err := ParseTarget(value)
+	if err != nil {
+		t.Fatalf("ParseTarget(%q) error = %v", value, err)
+	}
+	return target
+}
+
+func mustWriteLintFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("mkdir failed: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+}
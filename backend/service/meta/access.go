@@ -0,0 +1,204 @@
+package meta
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/viant/forge/backend/service/identity"
+	"github.com/viant/forge/backend/types"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	visibleForKey = "visibleFor"
+	requiresKey   = "requires"
+)
+
+// ApplyAccess removes nodes whose visibleFor or requires (see
+// types.AccessSpec) does not match caller, and drops the consumed keys so
+// access rules never reach the client. v is the value node decodes into;
+// only nodes of types carrying an AccessSpec, e.g. types.Container or
+// types.NavigationItem, are evaluated, so a visibleFor or requires key
+// elsewhere, e.g. a data source parameter, is left as is. A nil caller is
+// anonymous and only sees unrestricted nodes.
+func ApplyAccess(node *yaml.Node, v interface{}, caller *identity.Identity) {
+	if node == nil || v == nil {
+		return
+	}
+	applyAccess(node, reflect.TypeOf(v), caller)
+}
+
+// AllowsAccess reports whether caller satisfies both specs; nil or empty
+// specs allow everyone.
+func AllowsAccess(visibleFor, requires *types.AccessSpec, caller *identity.Identity) bool {
+	if !visibleFor.IsEmpty() && !anyAccess(visibleFor, caller) {
+		return false
+	}
+	return requires.IsEmpty() || allAccess(requires, caller)
+}
+
+var accessSpecType = reflect.TypeOf(&types.AccessSpec{})
+
+// hasAccessSpec reports whether struct type t declares visibleFor and
+// requires access specs.
+func hasAccessSpec(t reflect.Type) bool {
+	fields := fieldsOf(t)
+	return fields.byName[visibleForKey].typ == accessSpecType && fields.byName[requiresKey].typ == accessSpecType
+}
+
+// applyAccess walks node as type t and returns false when node should be
+// removed from its parent.
+func applyAccess(node *yaml.Node, t reflect.Type, caller *identity.Identity) bool {
+	if node.Kind == yaml.DocumentNode {
+		for _, item := range node.Content {
+			applyAccess(item, t, caller)
+		}
+		return true
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array:
+		if node.Kind != yaml.SequenceNode {
+			return true
+		}
+		content := node.Content[:0]
+		for _, item := range node.Content {
+			if applyAccess(item, t.Elem(), caller) {
+				content = append(content, item)
+			}
+		}
+		node.Content = content
+	case reflect.Map:
+		if node.Kind != yaml.MappingNode {
+			return true
+		}
+		content := node.Content[:0]
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if applyAccess(node.Content[i+1], t.Elem(), caller) {
+				content = append(content, node.Content[i], node.Content[i+1])
+			}
+		}
+		node.Content = content
+	case reflect.Struct:
+		if node.Kind != yaml.MappingNode {
+			return true
+		}
+		if hasAccessSpec(t) && !applyAccessSpec(node, caller) {
+			return false
+		}
+		fields := fieldsOf(t)
+		content := node.Content[:0]
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if field, ok := fields.byName[node.Content[i].Value]; ok && !applyAccess(node.Content[i+1], field.typ, caller) {
+				continue
+			}
+			content = append(content, node.Content[i], node.Content[i+1])
+		}
+		node.Content = content
+	}
+	return true
+}
+
+// applyAccessSpec evaluates the visibleFor and requires keys of node and
+// removes them; it returns false when caller may not see node.
+func applyAccessSpec(node *yaml.Node, caller *identity.Identity) bool {
+	visibleNode, requiresNode := mappingValue(node, visibleForKey), mappingValue(node, requiresKey)
+	visibleFor, visibleErr := decodeAccessSpec(visibleNode)
+	requires, requiresErr := decodeAccessSpec(requiresNode)
+	if visibleErr != nil || requiresErr != nil || !AllowsAccess(visibleFor, requires, caller) {
+		return false
+	}
+	if visibleNode != nil {
+		removeMappingKey(node, visibleForKey)
+	}
+	if requiresNode != nil {
+		removeMappingKey(node, requiresKey)
+	}
+	return true
+}
+
+// decodeAccessSpec decodes a visibleFor/requires value. An absent or empty
+// value restricts nothing; a value that does not decode to a restriction is
+// an error and denies everyone, so a malformed rule never exposes the node
+// it guards.
+func decodeAccessSpec(node *yaml.Node) (*types.AccessSpec, error) {
+	if node == nil || isEmptyAccessNode(node) {
+		return nil, nil
+	}
+	spec := &types.AccessSpec{}
+	if err := node.Decode(spec); err != nil {
+		return nil, err
+	}
+	if spec.IsEmpty() {
+		return nil, fmt.Errorf("access spec at line %d restricts nothing", node.Line)
+	}
+	return spec, nil
+}
+
+func isEmptyAccessNode(node *yaml.Node) bool {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		return node.Tag == "!!null" || strings.TrimSpace(node.Value) == ""
+	case yaml.SequenceNode, yaml.MappingNode:
+		return len(node.Content) == 0
+	}
+	return false
+}
+
+func anyAccess(spec *types.AccessSpec, caller *identity.Identity) bool {
+	for _, role := range spec.Roles {
+		if caller.HasRole(role) {
+			return true
+		}
+	}
+	for _, scope := range spec.Scopes {
+		if caller.HasScope(scope) {
+			return true
+		}
+	}
+	for claim, accepted := range spec.Claims {
+		if hasAnyClaim(caller, claim, accepted) {
+			return true
+		}
+	}
+	return false
+}
+
+func allAccess(spec *types.AccessSpec, caller *identity.Identity) bool {
+	for _, role := range spec.Roles {
+		if !caller.HasRole(role) {
+			return false
+		}
+	}
+	for _, scope := range spec.Scopes {
+		if !caller.HasScope(scope) {
+			return false
+		}
+	}
+	for claim, accepted := range spec.Claims {
+		if !hasAnyClaim(caller, claim, accepted) {
+			return false
+		}
+	}
+	return true
+}
+
+// hasAnyClaim matches a claim against one accepted value or a list of them.
+func hasAnyClaim(caller *identity.Identity, claim string, accepted interface{}) bool {
+	if accepted == nil {
+		return caller.HasClaimSet(claim)
+	}
+	values, ok := accepted.([]interface{})
+	if !ok {
+		values = []interface{}{accepted}
+	}
+	for _, value := range values {
+		if caller.HasClaim(claim, fmt.Sprint(value)) {
+			return true
+		}
+	}
+	return false
+}
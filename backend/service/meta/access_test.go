@@ -0,0 +1,138 @@
+package meta
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/viant/afs"
+	"github.com/viant/forge/backend/service/identity"
+	"github.com/viant/forge/backend/types"
+)
+
+func TestLoadForTarget_AppliesAccessRules(t *testing.T) {
+	root := t.TempDir()
+	mustWriteMetaFile(t, filepath.Join(root, "order.yaml"), `namespace: order
+view:
+  content:
+    table:
+      toolbar:
+        items:
+          - id: refresh
+          - id: approve
+            visibleFor: [manager, admin]
+          - id: delete
+            requires: {roles: [admin], scopes: [orders.delete]}
+      columns:
+        - id: name
+        - id: margin
+          visibleFor: {claims: {department: [finance, exec]}}
+    containers:
+      - id: audit
+        requires: {claims: {tenant: acme}}
+`)
+	service := New(afs.New(), root)
+	load := func(caller *identity.Identity) ([]string, []string, int) {
+		window := &types.Window{}
+		if err := service.LoadForTarget(context.Background(), "order.yaml", window, &TargetContext{Platform: "web", Identity: caller}); err != nil {
+			t.Fatalf("LoadForTarget() error = %v", err)
+		}
+		table := window.View.Content.Table
+		var items, columns []string
+		for _, item := range table.Toolbar.Items {
+			if item.VisibleFor != nil || item.Requires != nil {
+				t.Fatalf("expected access rules to be removed, got %+v", item)
+			}
+			items = append(items, item.ID)
+		}
+		for _, column := range table.Columns {
+			columns = append(columns, column.ID)
+		}
+		return items, columns, len(window.View.Content.Containers)
+	}
+
+	items, columns, containers := load(nil)
+	if !reflect.DeepEqual(items, []string{"refresh"}) || !reflect.DeepEqual(columns, []string{"name"}) || containers != 0 {
+		t.Fatalf("anonymous caller: unexpected items %v columns %v containers %d", items, columns, containers)
+	}
+	manager := &identity.Identity{Roles: []string{"manager"}, Claims: map[string]interface{}{"department": "finance", "tenant": "acme"}}
+	items, columns, containers = load(manager)
+	if !reflect.DeepEqual(items, []string{"refresh", "approve"}) || !reflect.DeepEqual(columns, []string{"name", "margin"}) || containers != 1 {
+		t.Fatalf("manager: unexpected items %v columns %v containers %d", items, columns, containers)
+	}
+	admin := &identity.Identity{Roles: []string{"admin"}, Scopes: []string{"orders.delete"}}
+	if items, _, _ = load(admin); !reflect.DeepEqual(items, []string{"refresh", "approve", "delete"}) {
+		t.Fatalf("admin: unexpected items %v", items)
+	}
+}
+
+func TestApplyAccess_MalformedSpecFailsClosed(t *testing.T) {
+	root := t.TempDir()
+	mustWriteMetaFile(t, filepath.Join(root, "order.yaml"), `namespace: order
+view:
+  content:
+    table:
+      toolbar:
+        items:
+          - id: refresh
+            visibleFor: ""
+          - id: approve
+            visibleFor: {roles: admin}
+          - id: audit
+            requires: {claims: tenant}
+          - id: typo
+            visibleFor: {role: [admin]}
+          - id: nested
+            requires: {roles: [{name: admin}]}
+`)
+	service := New(afs.New(), root)
+	load := func(caller *identity.Identity) []string {
+		window := &types.Window{}
+		if err := service.LoadForTarget(context.Background(), "order.yaml", window, &TargetContext{Platform: "web", Identity: caller}); err != nil {
+			t.Fatalf("LoadForTarget() error = %v", err)
+		}
+		var items []string
+		for _, item := range window.View.Content.Table.Toolbar.Items {
+			items = append(items, item.ID)
+		}
+		return items
+	}
+
+	if items := load(nil); !reflect.DeepEqual(items, []string{"refresh"}) {
+		t.Fatalf("anonymous caller: unexpected items %v", items)
+	}
+	admin := &identity.Identity{Roles: []string{"admin"}, Claims: map[string]interface{}{"tenant": "acme"}}
+	if items := load(admin); !reflect.DeepEqual(items, []string{"refresh", "approve", "audit"}) {
+		t.Fatalf("admin: unexpected items %v", items)
+	}
+}
+
+func TestLoadForTarget_KeepsAccessKeysOutsideAccessNodes(t *testing.T) {
+	root := t.TempDir()
+	mustWriteMetaFile(t, filepath.Join(root, "order.yaml"), `namespace: order
+view:
+  content:
+    table:
+      columns:
+        - id: customer
+          link:
+            windowKey: customer
+            parameters:
+              requires: {roles: [admin]}
+              visibleFor: manager
+`)
+	service := New(afs.New(), root)
+	window := &types.Window{}
+	if err := service.LoadForTarget(context.Background(), "order.yaml", window, &TargetContext{Platform: "web"}); err != nil {
+		t.Fatalf("LoadForTarget() error = %v", err)
+	}
+	columns := window.View.Content.Table.Columns
+	if len(columns) != 1 || columns[0].Link == nil {
+		t.Fatalf("expected the linked column to be kept, got %+v", columns)
+	}
+	parameters := columns[0].Link.Parameters
+	if parameters["requires"] == nil || parameters["visibleFor"] != "manager" {
+		t.Fatalf("expected link parameters to be kept, got %v", parameters)
+	}
+}
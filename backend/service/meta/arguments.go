@@ -0,0 +1,176 @@
+package meta
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const importMacroPrefix = "${"
+
+var importArgumentName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.-]*$`)
+
+// importDirective is a parsed $import(path[:key][, name=value...]).
+type importDirective struct {
+	Path      string
+	Key       string
+	Arguments []importArgument
+}
+
+// importArgument is one name=value pair; Value keeps the YAML type of bare
+// values so exact ${name} macros can be replaced with numbers or booleans.
+type importArgument struct {
+	Name  string
+	Value *yaml.Node
+}
+
+func (d *importDirective) argumentMap() map[string]string {
+	if len(d.Arguments) == 0 {
+		return nil
+	}
+	result := make(map[string]string, len(d.Arguments))
+	for _, argument := range d.Arguments {
+		result[argument.Name] = argument.Value.Value
+	}
+	return result
+}
+
+// parseImportDirective parses an $import directive. Arguments after the path
+// are comma separated name=value pairs; values may be quoted.
+func parseImportDirective(value string) (*importDirective, error) {
+	value = strings.TrimSpace(value)
+	if !isImportDirective(value) {
+		return nil, fmt.Errorf("not an import directive: %s", value)
+	}
+	// Extract the content inside the parentheses.
+	start := strings.Index(value, "(")
+	end := strings.LastIndex(value, ")")
+	if start == -1 || end == -1 || start >= end {
+		return nil, fmt.Errorf("invalid import directive syntax: %s", value)
+	}
+	parts, err := splitImportArguments(value[start+1 : end])
+	if err != nil {
+		return nil, fmt.Errorf("invalid import directive %s: %w", value, err)
+	}
+	// Remove surrounding quotes if present.
+	pathValue := strings.Trim(strings.TrimSpace(parts[0]), "\"'")
+
+	// Check if a key is specified after a colon.
+	result := &importDirective{}
+	pathParts := strings.SplitN(pathValue, ":", 2)
+	result.Path = pathParts[0]
+	if len(pathParts) > 1 {
+		result.Key = pathParts[1]
+	}
+	if !strings.HasSuffix(result.Path, ".yaml") {
+		result.Path += ".yaml"
+	}
+	seen := map[string]bool{}
+	for _, part := range parts[1:] {
+		argument, err := parseImportArgument(part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid import directive %s: %w", value, err)
+		}
+		if seen[argument.Name] {
+			return nil, fmt.Errorf("invalid import directive %s: duplicate argument %q", value, argument.Name)
+		}
+		seen[argument.Name] = true
+		result.Arguments = append(result.Arguments, argument)
+	}
+	return result, nil
+}
+
+// splitImportArguments splits on commas outside quotes.
+func splitImportArguments(value string) ([]string, error) {
+	var result []string
+	var quote rune
+	var escaped bool
+	start := 0
+	for i, r := range value {
+		switch {
+		case escaped:
+			escaped = false
+		case quote != 0 && r == '\\':
+			escaped = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == ',':
+			result = append(result, value[start:i])
+			start = i + 1
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote")
+	}
+	return append(result, value[start:]), nil
+}
+
+func parseImportArgument(value string) (importArgument, error) {
+	name, raw, ok := strings.Cut(value, "=")
+	name = strings.TrimSpace(name)
+	if !ok || !importArgumentName.MatchString(name) {
+		return importArgument{}, fmt.Errorf("expected name=value argument, got %q", strings.TrimSpace(value))
+	}
+	raw = strings.TrimSpace(raw)
+	switch {
+	case len(raw) >= 2 && raw[0] == '"' && raw[len(raw)-1] == '"':
+		text, err := strconv.Unquote(raw)
+		if err != nil {
+			return importArgument{}, fmt.Errorf("argument %q: %w", name, err)
+		}
+		return importArgument{Name: name, Value: &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: text}}, nil
+	case len(raw) >= 2 && raw[0] == '\'' && raw[len(raw)-1] == '\'':
+		return importArgument{Name: name, Value: &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: raw[1 : len(raw)-1]}}, nil
+	}
+	var node yaml.Node
+	if raw != "" && yaml.Unmarshal([]byte(raw), &node) == nil && len(node.Content) == 1 && node.Content[0].Kind == yaml.ScalarNode {
+		return importArgument{Name: name, Value: node.Content[0]}, nil
+	}
+	return importArgument{Name: name, Value: &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: raw}}, nil
+}
+
+// substituteImportArguments replaces ${name} macros in node with the
+// directive's arguments. A scalar that is exactly one macro takes the
+// argument's YAML type; embedded macros are replaced as text. Macros without
+// a matching argument are left for the runtime.
+func substituteImportArguments(node *yaml.Node, arguments []importArgument) {
+	if node == nil || len(arguments) == 0 {
+		return
+	}
+	if node.Kind == yaml.ScalarNode {
+		if node.Tag != "!!str" || !strings.Contains(node.Value, importMacroPrefix) {
+			return
+		}
+		if name, exact := exactImportMacro(node.Value); exact {
+			for _, argument := range arguments {
+				if argument.Name == name {
+					node.Tag, node.Value, node.Style = argument.Value.Tag, argument.Value.Value, argument.Value.Style
+					return
+				}
+			}
+		}
+		for _, argument := range arguments {
+			node.Value = strings.ReplaceAll(node.Value, importMacroPrefix+argument.Name+"}", argument.Value.Value)
+		}
+		return
+	}
+	for _, child := range node.Content {
+		substituteImportArguments(child, arguments)
+	}
+}
+
+func exactImportMacro(value string) (string, bool) {
+	value = strings.TrimSpace(value)
+	if !strings.HasPrefix(value, importMacroPrefix) || !strings.HasSuffix(value, "}") || strings.Count(value, importMacroPrefix) != 1 {
+		return "", false
+	}
+	name := strings.TrimSpace(value[2 : len(value)-1])
+	return name, name != ""
+}
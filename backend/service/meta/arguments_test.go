@@ -0,0 +1,94 @@
+package meta
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/viant/afs"
+)
+
+func TestLoad_ImportArgumentsSubstituteMacros(t *testing.T) {
+	root := t.TempDir()
+	mustWriteMetaFile(t, filepath.Join(root, "window", "common", "lookup.yaml"), `lookup:
+  dataSource: ${dataSource}
+  title: Select ${title}
+  size: ${pageSize}
+  uri: /v1/${dataSource}/{id}
+  owner: ${owner}
+  footer: $import(footer.yaml, label="${title}")
+`)
+	mustWriteMetaFile(t, filepath.Join(root, "window", "common", "footer.yaml"), "label: ${label}\n")
+	mustWriteMetaFile(t, filepath.Join(root, "window", "order", "main.yaml"), `advertiser: $import(../common/lookup.yaml:lookup, dataSource=advertiser, title="Pick, advertiser", pageSize=25)
+campaign: $import(../common/lookup.yaml:lookup, dataSource=campaign, title='campaign', pageSize="25")
+`)
+
+	service := New(afs.New(), filepath.Join(root, "window"))
+	var decoded map[string]map[string]any
+	if err := service.Load(context.Background(), "order/main.yaml", &decoded); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	advertiser, campaign := decoded["advertiser"], decoded["campaign"]
+	if advertiser["dataSource"] != "advertiser" || advertiser["title"] != "Select Pick, advertiser" || advertiser["uri"] != "/v1/advertiser/{id}" {
+		t.Fatalf("unexpected advertiser lookup %v", advertiser)
+	}
+	if advertiser["size"] != 25 || campaign["size"] != "25" {
+		t.Fatalf("expected typed bare argument and string quoted argument, got %#v and %#v", advertiser["size"], campaign["size"])
+	}
+	if advertiser["owner"] != "${owner}" {
+		t.Fatalf("expected unknown macro to be kept, got %v", advertiser["owner"])
+	}
+	if footer, _ := advertiser["footer"].(map[string]any); footer["label"] != "Pick, advertiser" {
+		t.Fatalf("expected argument forwarded to nested import, got %v", advertiser["footer"])
+	}
+	if campaign["dataSource"] != "campaign" || campaign["title"] != "Select campaign" {
+		t.Fatalf("unexpected campaign lookup %v", campaign)
+	}
+}
+
+func TestLoad_InvalidImportArgumentReportsDirective(t *testing.T) {
+	root := t.TempDir()
+	mustWriteMetaFile(t, filepath.Join(root, "window", "order", "table.yaml"), "id: table\n")
+	mustWriteMetaFile(t, filepath.Join(root, "window", "order", "main.yaml"), "table: $import(table.yaml, dataSource)\n")
+
+	service := New(afs.New(), filepath.Join(root, "window"))
+	var decoded map[string]any
+	err := service.Load(context.Background(), "order/main.yaml", &decoded)
+	var loadErr *LoadError
+	if !errors.As(err, &loadErr) || loadErr.Line != 1 || !strings.Contains(err.Error(), "expected name=value") {
+		t.Fatalf("expected LoadError for invalid argument, got %v", err)
+	}
+}
+
+func TestParseImportDirective(t *testing.T) {
+	testCases := []struct {
+		directive string
+		path      string
+		key       string
+		arguments map[string]string
+	}{
+		{directive: "$import('content.yaml')", path: "content.yaml"},
+		{directive: "$import(common/lookup:lookup.advertiser)", path: "common/lookup.yaml", key: "lookup.advertiser"},
+		{directive: `$import(common/lookup.yaml, dataSource=advertiser, title="Pick \"advertiser\"")`, path: "common/lookup.yaml", arguments: map[string]string{"dataSource": "advertiser", "title": `Pick "advertiser"`}},
+	}
+	for _, testCase := range testCases {
+		directive, err := parseImportDirective(testCase.directive)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", testCase.directive, err)
+		}
+		if directive.Path != testCase.path || directive.Key != testCase.key {
+			t.Fatalf("%s: unexpected path %q key %q", testCase.directive, directive.Path, directive.Key)
+		}
+		arguments := directive.argumentMap()
+		if len(arguments) != len(testCase.arguments) {
+			t.Fatalf("%s: unexpected arguments %v", testCase.directive, arguments)
+		}
+		for name, value := range testCase.arguments {
+			if arguments[name] != value {
+				t.Fatalf("%s: expected %s=%q, got %q", testCase.directive, name, value, arguments[name])
+			}
+		}
+	}
+}
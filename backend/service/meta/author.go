@@ -0,0 +1,587 @@
+package meta
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+
+	"github.com/viant/afs/file"
+	"github.com/viant/afs/url"
+	"github.com/viant/forge/backend/types"
+	"gopkg.in/yaml.v3"
+)
+
+// SaveResult describes a successful write.
+type SaveResult struct {
+	// URL is the window file the target resolves to.
+	URL string `json:"url"`
+	// Files lists every file written, including imported files edits were
+	// written through to; it is empty when nothing changed.
+	Files []string `json:"files,omitempty"`
+	// Diagnostics holds validation warnings.
+	Diagnostics []Diagnostic `json:"diagnostics,omitempty"`
+}
+
+// SaveOption customizes SaveWindow.
+type SaveOption func(*saveOptions)
+
+type saveOptions struct {
+	fork bool
+}
+
+// WithFork makes SaveWindow write to the most specific branch for target,
+// e.g. order/ios/phone/main.yaml for ios/phone. When the window so far came
+// from a less specific branch, the new file starts as a copy of that one,
+// comments and imports included, before document is merged into it.
+func WithFork() SaveOption {
+	return func(o *saveOptions) {
+		o.fork = true
+	}
+}
+
+// SaveWindow writes document, a window in YAML or JSON, to the file basePath
+// resolves to for target (see ResolveWindowBase), so the branch the window
+// is served from is edited; see WithFork to create a target-specific branch
+// instead. A window that does not exist yet is created at basePath. The
+// document is validated against types.Window first and rejected with a
+// ValidationError.
+//
+// The existing file is edited in place rather than re-marshaled, so comments,
+// key order, quoting and the indent width survive; blank lines and line
+// wrapping are normalized by the YAML encoder. Sequence items are matched by
+// id. A value the file takes from $import stays an import when the document
+// holds the imported content unchanged; changes are written through to the
+// imported file, or rejected when the import has arguments or is an $extend
+// base.
+func (l *Service) SaveWindow(ctx context.Context, basePath string, document []byte, target *TargetContext, options ...SaveOption) (*SaveResult, error) {
+	config := &saveOptions{}
+	for _, option := range options {
+		if option != nil {
+			option(config)
+		}
+	}
+	resolved, err := l.ResolveWindowBase(ctx, basePath, target)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+		resolved = ""
+	}
+	URL, forkURL := l.getURL(basePath+".yaml"), ""
+	if resolved != "" {
+		URL = l.getURL(resolved + ".yaml")
+	}
+	if config.fork {
+		if branch := l.getURL(branchCandidates(basePath, target)[0] + ".yaml"); branch != URL {
+			if resolved != "" {
+				forkURL = URL
+			}
+			URL = branch
+		}
+	}
+	return l.save(ctx, URL, forkURL, "", document, &types.Window{}, target)
+}
+
+// SaveContainer writes document, a single container in YAML or JSON, over the
+// container at idPath in the window basePath resolves to for target. idPath
+// lists container ids from the outermost one, separated by "/", e.g.
+// "orders/summary"; each id is searched for below the previous container,
+// following $import references. Validation and write-back work as in
+// SaveWindow.
+func (l *Service) SaveContainer(ctx context.Context, basePath, idPath string, document []byte, target *TargetContext) (*SaveResult, error) {
+	if strings.Trim(idPath, "/") == "" {
+		return nil, fmt.Errorf("container id path is required")
+	}
+	resolved, err := l.ResolveWindowBase(ctx, basePath, target)
+	if err != nil {
+		return nil, err
+	}
+	return l.save(ctx, l.getURL(resolved+".yaml"), "", idPath, document, &types.Container{}, target)
+}
+
+// save merges document into the node at idPath in URL; a missing URL is
+// created as a copy of forkURL when set.
+func (l *Service) save(ctx context.Context, URL, forkURL, idPath string, document []byte, v interface{}, target *TargetContext) (*SaveResult, error) {
+	desired := &yaml.Node{}
+	if err := yaml.Unmarshal(document, desired); err != nil {
+		line, column := yamlErrorPosition(err)
+		return nil, &LoadError{URL: URL, Line: line, Column: column, Err: err}
+	}
+	if desired.Kind != yaml.DocumentNode || len(desired.Content) == 0 {
+		return nil, fmt.Errorf("empty document")
+	}
+	desired = desired.Content[0]
+	resetStyle(desired)
+
+	editor := &authoring{service: l, target: target, files: map[string]*authorFile{}}
+	root, err := editor.open(ctx, URL, idPath == "")
+	if err != nil {
+		return nil, err
+	}
+	if forkURL != "" && root.content() == nil {
+		source, err := editor.open(ctx, forkURL, false)
+		if err != nil {
+			return nil, err
+		}
+		root.node, root.indent = cloneNode(source.node), source.indent
+	}
+	scope := &authorScope{file: root, baseDir: parentURL(root.URL)}
+	node := root.content()
+	if idPath != "" {
+		if node, scope, err = editor.findContainer(ctx, node, scope, strings.Split(strings.Trim(idPath, "/"), "/")); err != nil {
+			return nil, err
+		}
+	}
+	diagnostics, err := editor.validate(ctx, desired, scope, v)
+	if err != nil {
+		return nil, err
+	}
+	result := &SaveResult{URL: root.URL, Diagnostics: diagnostics}
+	if node == nil {
+		root.node.Content = []*yaml.Node{desired}
+		root.changed = true
+	} else if err := editor.merge(ctx, node, desired, scope, "$"); err != nil {
+		return nil, err
+	}
+	for _, edited := range editor.order {
+		if !edited.changed {
+			continue
+		}
+		written, err := editor.write(ctx, edited)
+		if err != nil {
+			return nil, err
+		}
+		result.Files = append(result.Files, written)
+	}
+	if len(result.Files) > 0 {
+		l.InvalidateCache()
+	}
+	return result, nil
+}
+
+// authoring holds the raw files touched by one save.
+type authoring struct {
+	service *Service
+	target  *TargetContext
+	files   map[string]*authorFile
+	order   []*authorFile
+}
+
+// authorFile is a raw, unresolved YAML document; indent is the width it was
+// written with.
+type authorFile struct {
+	URL     string
+	node    *yaml.Node
+	indent  int
+	changed bool
+}
+
+func (f *authorFile) content() *yaml.Node {
+	if len(f.node.Content) == 0 {
+		return nil
+	}
+	return f.node.Content[0]
+}
+
+// authorScope is the file a node being edited belongs to. readOnly is set
+// below nodes that cannot be written back.
+type authorScope struct {
+	file     *authorFile
+	baseDir  string
+	readOnly error
+}
+
+// open reads URL without resolving imports; a missing file is only allowed
+// when create is set.
+func (a *authoring) open(ctx context.Context, URL string, create bool) (*authorFile, error) {
+	if existing, ok := a.files[URL]; ok {
+		return existing, nil
+	}
+	l := a.service
+	located, exists, err := l.locate(ctx, URL)
+	if err != nil {
+		return nil, err
+	}
+	result := &authorFile{URL: URL, node: &yaml.Node{Kind: yaml.DocumentNode}, indent: defaultIndent}
+	if exists {
+		data, err := l.fs.DownloadWithURL(ctx, located, l.options...)
+		if err != nil {
+			return nil, err
+		}
+		result.indent = detectIndent(data)
+		if err := yaml.Unmarshal(data, result.node); err != nil {
+			line, column := yamlErrorPosition(err)
+			return nil, &LoadError{URL: located, Line: line, Column: column, Err: err}
+		}
+		if result.node.Kind == 0 {
+			result.node.Kind = yaml.DocumentNode
+		}
+	} else if !create {
+		return nil, &LoadError{URL: URL, Err: fmt.Errorf("open %s: %w", URL, fs.ErrNotExist)}
+	}
+	a.files[URL] = result
+	a.order = append(a.order, result)
+	return result, nil
+}
+
+// write stores file in the first overlay root, so edits to a file served
+// from a lower root shadow it rather than change the shared copy.
+func (a *authoring) write(ctx context.Context, edited *authorFile) (string, error) {
+	l := a.service
+	URL := edited.URL
+	if rel, ok := l.rootRelative(URL); ok && len(l.roots) > 0 {
+		URL = url.Join(l.roots[0], rel)
+	}
+	buffer := &bytes.Buffer{}
+	encoder := yaml.NewEncoder(buffer)
+	encoder.SetIndent(edited.indent)
+	if err := encoder.Encode(edited.node); err != nil {
+		return "", fmt.Errorf("encode %s: %w", URL, err)
+	}
+	if err := encoder.Close(); err != nil {
+		return "", fmt.Errorf("encode %s: %w", URL, err)
+	}
+	if err := l.fs.Upload(ctx, URL, file.DefaultFileOsMode, buffer, l.options...); err != nil {
+		return "", fmt.Errorf("write %s: %w", URL, err)
+	}
+	return URL, nil
+}
+
+// validate resolves the imports a document refers to and validates it
+// against v.
+func (a *authoring) validate(ctx context.Context, desired *yaml.Node, scope *authorScope, v interface{}) ([]Diagnostic, error) {
+	resolved, session, err := a.resolve(ctx, desired, scope)
+	if err != nil {
+		return nil, err
+	}
+	validator := a.service.strict
+	if validator == nil {
+		validator = newValidator()
+	}
+	diagnostics := validator.validate(resolved, scope.file.URL, session.origins, v)
+	if HasErrors(diagnostics) {
+		return nil, &ValidationError{URL: scope.file.URL, Diagnostics: diagnostics}
+	}
+	return diagnostics, nil
+}
+
+// resolve returns a copy of node with $import and $extend resolved as if it
+// were part of scope's file.
+func (a *authoring) resolve(ctx context.Context, node *yaml.Node, scope *authorScope) (*yaml.Node, *loadSession, error) {
+	result := cloneNode(node)
+	session := newLoadSession(a.target)
+	if err := session.enter(scope.file.URL); err != nil {
+		return nil, nil, err
+	}
+	defer session.leave()
+	if err := a.service.processNode(ctx, result, scope.baseDir, session); err != nil {
+		return nil, nil, err
+	}
+	return result, session, nil
+}
+
+// follow returns the raw node an $import directive refers to and the scope
+// of its file.
+func (a *authoring) follow(ctx context.Context, directiveValue string, scope *authorScope) (*yaml.Node, *authorScope, error) {
+	directive, err := parseImportDirective(directiveValue)
+	if err != nil {
+		return nil, nil, err
+	}
+	URL, _, err := a.service.resolveImportURL(ctx, scope.baseDir, directive.Path, a.target)
+	if err != nil {
+		return nil, nil, err
+	}
+	imported, err := a.open(ctx, URL, false)
+	if err != nil {
+		return nil, nil, err
+	}
+	node := imported.content()
+	if directive.Key != "" {
+		if node, err = getNodeByKey(imported.node, directive.Key); err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", URL, err)
+		}
+	}
+	if node == nil {
+		return nil, nil, fmt.Errorf("%s: empty document", URL)
+	}
+	next := &authorScope{file: imported, baseDir: parentURL(URL), readOnly: scope.readOnly}
+	if len(directive.Arguments) > 0 && next.readOnly == nil {
+		next.readOnly = fmt.Errorf("%s is imported with arguments", URL)
+	}
+	return node, next, nil
+}
+
+// findContainer returns the mapping for the container with the last id in
+// ids, searching each id below the previous match.
+func (a *authoring) findContainer(ctx context.Context, node *yaml.Node, scope *authorScope, ids []string) (*yaml.Node, *authorScope, error) {
+	for i, id := range ids {
+		found, foundScope, err := a.findByID(ctx, node, scope, id, i > 0)
+		if err != nil {
+			return nil, nil, err
+		}
+		if found == nil {
+			return nil, nil, fmt.Errorf("container %s not found in %s", strings.Join(ids[:i+1], "/"), scope.file.URL)
+		}
+		node, scope = found, foundScope
+	}
+	return node, scope, nil
+}
+
+// findByID searches node depth-first, in document order, for a mapping with
+// id. The node itself is skipped when it is the previous match.
+func (a *authoring) findByID(ctx context.Context, node *yaml.Node, scope *authorScope, id string, skipSelf bool) (*yaml.Node, *authorScope, error) {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		if !isImportDirective(node.Value) {
+			return nil, nil, nil
+		}
+		imported, importedScope, err := a.follow(ctx, node.Value, scope)
+		if err != nil {
+			return nil, nil, err
+		}
+		return a.findByID(ctx, imported, importedScope, id, false)
+	case yaml.MappingNode:
+		if !skipSelf && itemID(node) == id {
+			return node, scope, nil
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == extendKey {
+				continue
+			}
+			if found, foundScope, err := a.findByID(ctx, node.Content[i+1], scope, id, false); found != nil || err != nil {
+				return found, foundScope, err
+			}
+		}
+	case yaml.SequenceNode:
+		for _, item := range node.Content {
+			if found, foundScope, err := a.findByID(ctx, item, scope, id, false); found != nil || err != nil {
+				return found, foundScope, err
+			}
+		}
+	}
+	return nil, nil, nil
+}
+
+// merge edits existing in place until it matches desired. path is the YAML
+// path of existing, used in errors.
+func (a *authoring) merge(ctx context.Context, existing, desired *yaml.Node, scope *authorScope, path string) error {
+	if existing.Kind == yaml.ScalarNode && isImportDirective(existing.Value) && !sameScalar(existing, desired) {
+		return a.mergeImport(ctx, existing, desired, scope, path)
+	}
+	if existing.Kind == yaml.MappingNode && mappingValue(existing, extendKey) != nil && mappingValue(desired, extendKey) == nil {
+		equal, err := a.resolvesTo(ctx, existing, desired, scope)
+		if err != nil || equal {
+			return err
+		}
+		return fmt.Errorf("%s: %s in %s cannot be written back; edit the %s patch instead", path, extendKey, scope.file.URL, extendKey)
+	}
+	if existing.Kind != desired.Kind || existing.Kind == yaml.AliasNode {
+		return a.replace(existing, desired, scope, path)
+	}
+	switch existing.Kind {
+	case yaml.ScalarNode:
+		if sameScalar(existing, desired) {
+			return nil
+		}
+		return a.replace(existing, desired, scope, path)
+	case yaml.MappingNode:
+		return a.mergeMapping(ctx, existing, desired, scope, path)
+	case yaml.SequenceNode:
+		return a.mergeSequence(ctx, existing, desired, scope, path)
+	}
+	return nil
+}
+
+// mergeImport keeps an $import whose content already matches desired and
+// otherwise writes desired through to the imported file.
+func (a *authoring) mergeImport(ctx context.Context, existing, desired *yaml.Node, scope *authorScope, path string) error {
+	equal, err := a.resolvesTo(ctx, existing, desired, scope)
+	if err != nil || equal {
+		return err
+	}
+	imported, importedScope, err := a.follow(ctx, existing.Value, scope)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return a.merge(ctx, imported, desired, importedScope, path)
+}
+
+// resolvesTo reports whether existing and desired resolve to equal content.
+func (a *authoring) resolvesTo(ctx context.Context, existing, desired *yaml.Node, scope *authorScope) (bool, error) {
+	resolvedExisting, _, err := a.resolve(ctx, existing, scope)
+	if err != nil {
+		return false, err
+	}
+	resolvedDesired, _, err := a.resolve(ctx, desired, scope)
+	if err != nil {
+		return false, err
+	}
+	return equalNodes(resolvedExisting, resolvedDesired), nil
+}
+
+func (a *authoring) mergeMapping(ctx context.Context, existing, desired *yaml.Node, scope *authorScope, path string) error {
+	wanted := map[string]bool{}
+	for i := 0; i+1 < len(desired.Content); i += 2 {
+		key, value := desired.Content[i].Value, desired.Content[i+1]
+		wanted[key] = true
+		childPath := path + "." + key
+		if current := mappingValue(existing, key); current != nil {
+			if err := a.merge(ctx, current, value, scope, childPath); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := a.edit(scope, childPath); err != nil {
+			return err
+		}
+		existing.Content = append(existing.Content, desired.Content[i], value)
+	}
+	content := existing.Content[:0]
+	for i := 0; i+1 < len(existing.Content); i += 2 {
+		if !wanted[existing.Content[i].Value] {
+			if err := a.edit(scope, path+"."+existing.Content[i].Value); err != nil {
+				return err
+			}
+			continue
+		}
+		content = append(content, existing.Content[i], existing.Content[i+1])
+	}
+	existing.Content = content
+	return nil
+}
+
+// mergeSequence matches items by id, falling back to position for items
+// without one, and reorders existing items to the desired order.
+func (a *authoring) mergeSequence(ctx context.Context, existing, desired *yaml.Node, scope *authorScope, path string) error {
+	used := make([]bool, len(existing.Content))
+	content := make([]*yaml.Node, 0, len(desired.Content))
+	changed := len(existing.Content) != len(desired.Content)
+	for i, item := range desired.Content {
+		index := -1
+		if id := itemID(item); id != "" {
+			index = indexByID(existing, id)
+		} else if i < len(existing.Content) && itemID(existing.Content[i]) == "" {
+			index = i
+		}
+		itemPath := fmt.Sprintf("%s[%d]", path, i)
+		if index == -1 || used[index] {
+			changed = true
+			content = append(content, item)
+			continue
+		}
+		used[index] = true
+		changed = changed || index != i
+		if err := a.merge(ctx, existing.Content[index], item, scope, itemPath); err != nil {
+			return err
+		}
+		content = append(content, existing.Content[index])
+	}
+	if !changed {
+		return nil
+	}
+	if err := a.edit(scope, path); err != nil {
+		return err
+	}
+	existing.Content = content
+	return nil
+}
+
+// replace overwrites existing with desired, keeping existing comments.
+func (a *authoring) replace(existing, desired *yaml.Node, scope *authorScope, path string) error {
+	if err := a.edit(scope, path); err != nil {
+		return err
+	}
+	head, line, foot := existing.HeadComment, existing.LineComment, existing.FootComment
+	*existing = *desired
+	if existing.HeadComment == "" && existing.LineComment == "" && existing.FootComment == "" {
+		existing.HeadComment, existing.LineComment, existing.FootComment = head, line, foot
+	}
+	return nil
+}
+
+// edit marks scope's file changed, failing in read-only scopes.
+func (a *authoring) edit(scope *authorScope, path string) error {
+	if scope.readOnly != nil {
+		return fmt.Errorf("%s: cannot write back: %w", path, scope.readOnly)
+	}
+	scope.file.changed = true
+	return nil
+}
+
+func sameScalar(existing, desired *yaml.Node) bool {
+	return existing.Kind == yaml.ScalarNode && desired.Kind == yaml.ScalarNode &&
+		existing.Value == desired.Value && existing.ShortTag() == desired.ShortTag()
+}
+
+// equalNodes compares resolved content; mapping key order is ignored.
+func equalNodes(left, right *yaml.Node) bool {
+	left, right = getContentNode(left), getContentNode(right)
+	for left.Kind == yaml.AliasNode && left.Alias != nil {
+		left = left.Alias
+	}
+	for right.Kind == yaml.AliasNode && right.Alias != nil {
+		right = right.Alias
+	}
+	if left.Kind != right.Kind || len(left.Content) != len(right.Content) {
+		return false
+	}
+	switch left.Kind {
+	case yaml.ScalarNode:
+		return sameScalar(left, right)
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(left.Content); i += 2 {
+			other := mappingValue(right, left.Content[i].Value)
+			if other == nil || !equalNodes(left.Content[i+1], other) {
+				return false
+			}
+		}
+	default:
+		for i := range left.Content {
+			if !equalNodes(left.Content[i], right.Content[i]) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// resetStyle drops the styles and positions of a parsed document, so JSON
+// input is written in block style.
+func resetStyle(node *yaml.Node) {
+	node.Style &^= yaml.FlowStyle | yaml.DoubleQuotedStyle | yaml.SingleQuotedStyle
+	node.Line, node.Column = 0, 0
+	for _, item := range node.Content {
+		resetStyle(item)
+	}
+}
+
+// defaultIndent is the indent width of files SaveWindow creates.
+const defaultIndent = 2
+
+// detectIndent returns the smallest indent of a content line in data, the
+// width the file is written with, or defaultIndent for flat files.
+func detectIndent(data []byte) int {
+	result := 0
+	for _, line := range strings.Split(string(data), "\n") {
+		trimmed := strings.TrimLeft(line, " ")
+		width := len(line) - len(trimmed)
+		if width == 0 || trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if result == 0 || width < result {
+			result = width
+		}
+	}
+	if result < 2 || result > 9 {
+		return defaultIndent
+	}
+	return result
+}
+
+func parentURL(URL string) string {
+	parent, _ := url.Split(URL, file.Scheme)
+	return parent
+}
@@ -0,0 +1,255 @@
+package meta
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/viant/afs"
+	"github.com/viant/forge/backend/types"
+)
+
+func TestSaveWindow_EditsNodeTreeInPlace(t *testing.T) {
+	root := t.TempDir()
+	mustWriteMetaFile(t, filepath.Join(root, "order", "main.yaml"), `# Orders window
+namespace: order
+view:
+  content:
+    table:
+      # visible columns
+      columns:
+        - id: name
+          name: Name # shown first
+        - id: total
+          name: Total
+      toolbar: $import(toolbar.yaml)
+`)
+	mustWriteMetaFile(t, filepath.Join(root, "order", "toolbar.yaml"), `items:
+  - id: refresh
+`)
+	service := New(afs.New(), root)
+	ctx := context.Background()
+
+	result, err := service.SaveWindow(ctx, "order/main", []byte(`namespace: order
+view:
+  content:
+    table:
+      columns:
+        - id: total
+          name: Grand total
+        - id: name
+          name: Name
+        - id: region
+      toolbar:
+        items:
+          - id: refresh
+`), nil)
+	if err != nil {
+		t.Fatalf("SaveWindow() error = %v", err)
+	}
+	if len(result.Files) != 1 {
+		t.Fatalf("expected only the window file to be written, got %v", result.Files)
+	}
+	actual := mustReadMetaFile(t, filepath.Join(root, "order", "main.yaml"))
+	for _, expected := range []string{"# Orders window", "# visible columns", "name: Name # shown first", "name: Grand total", "toolbar: $import(toolbar.yaml)", "- id: region"} {
+		if !strings.Contains(actual, expected) {
+			t.Fatalf("expected %q in saved window:\n%s", expected, actual)
+		}
+	}
+	if strings.Index(actual, "id: total") > strings.Index(actual, "id: name") {
+		t.Fatalf("expected columns in the saved order:\n%s", actual)
+	}
+
+	window := &types.Window{}
+	if err := service.Load(ctx, "order/main.yaml", window); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if columns := window.View.Content.Table.Columns; len(columns) != 3 || columns[0].Name != "Grand total" {
+		t.Fatalf("unexpected saved columns %+v", columns)
+	}
+}
+
+func TestSaveWindow_WritesThroughImports(t *testing.T) {
+	root := t.TempDir()
+	mustWriteMetaFile(t, filepath.Join(root, "order", "main.yaml"), `namespace: order
+view:
+  content:
+    table:
+      toolbar: $import(toolbar.yaml)
+`)
+	mustWriteMetaFile(t, filepath.Join(root, "order", "toolbar.yaml"), `# shared toolbar
+items:
+  - id: refresh
+`)
+	service := New(afs.New(), root)
+	result, err := service.SaveWindow(context.Background(), "order/main", []byte(`{"namespace":"order","view":{"content":{"table":{"toolbar":{"items":[{"id":"refresh"},{"id":"export"}]}}}}}`), nil)
+	if err != nil {
+		t.Fatalf("SaveWindow() error = %v", err)
+	}
+	if len(result.Files) != 1 || !strings.HasSuffix(result.Files[0], "toolbar.yaml") {
+		t.Fatalf("expected the imported file to be written, got %v", result.Files)
+	}
+	if actual := mustReadMetaFile(t, filepath.Join(root, "order", "main.yaml")); !strings.Contains(actual, "$import(toolbar.yaml)") {
+		t.Fatalf("expected import to be kept:\n%s", actual)
+	}
+	if actual := mustReadMetaFile(t, filepath.Join(root, "order", "toolbar.yaml")); !strings.Contains(actual, "# shared toolbar") || !strings.Contains(actual, "- id: export") {
+		t.Fatalf("expected change written through to the import:\n%s", actual)
+	}
+}
+
+func TestSaveWindow_RejectsInvalidDocument(t *testing.T) {
+	root := t.TempDir()
+	original := "namespace: order\nview:\n  content:\n    id: main\n"
+	mustWriteMetaFile(t, filepath.Join(root, "order", "main.yaml"), original)
+	service := New(afs.New(), root)
+	_, err := service.SaveWindow(context.Background(), "order/main", []byte("namespace: order\nview:\n  content:\n    colums: []\n"), nil)
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) || validationErr.Diagnostics[0].Code != "unknownField" {
+		t.Fatalf("expected unknownField validation error, got %v", err)
+	}
+	if actual := mustReadMetaFile(t, filepath.Join(root, "order", "main.yaml")); actual != original {
+		t.Fatalf("expected file to be unchanged, got:\n%s", actual)
+	}
+}
+
+func TestSaveWindow_TargetBranch(t *testing.T) {
+	root := t.TempDir()
+	mustWriteMetaFile(t, filepath.Join(root, "order", "main.yaml"), "namespace: order\n")
+	mustWriteMetaFile(t, filepath.Join(root, "order", "ios", "main.yaml"), "# iOS layout\nnamespace: order\nregion: iOS\n")
+	service := New(afs.New(), root)
+	ctx := context.Background()
+	phone := &TargetContext{Platform: "ios", FormFactor: "phone"}
+
+	if _, err := service.SaveWindow(ctx, "order/main", []byte("namespace: order\nregion: Apple\n"), phone); err != nil {
+		t.Fatalf("SaveWindow() error = %v", err)
+	}
+	if actual := mustReadMetaFile(t, filepath.Join(root, "order", "ios", "main.yaml")); actual != "# iOS layout\nnamespace: order\nregion: Apple\n" {
+		t.Fatalf("expected the resolved ios branch to be written, got:\n%s", actual)
+	}
+	if _, err := os.Stat(filepath.Join(root, "order", "ios", "phone", "main.yaml")); !os.IsNotExist(err) {
+		t.Fatalf("expected no ios/phone branch without a fork, got %v", err)
+	}
+
+	if _, err := service.SaveWindow(ctx, "order/main", []byte("namespace: order\nregion: iPhone\n"), phone, WithFork()); err != nil {
+		t.Fatalf("SaveWindow() error = %v", err)
+	}
+	if actual := mustReadMetaFile(t, filepath.Join(root, "order", "ios", "phone", "main.yaml")); actual != "# iOS layout\nnamespace: order\nregion: iPhone\n" {
+		t.Fatalf("expected ios/phone branch to be forked from ios, got:\n%s", actual)
+	}
+	if actual := mustReadMetaFile(t, filepath.Join(root, "order", "ios", "main.yaml")); actual != "# iOS layout\nnamespace: order\nregion: Apple\n" {
+		t.Fatalf("expected less specific ios branch to be unchanged, got:\n%s", actual)
+	}
+	if actual := mustReadMetaFile(t, filepath.Join(root, "order", "main.yaml")); actual != "namespace: order\n" {
+		t.Fatalf("expected base window to be unchanged, got:\n%s", actual)
+	}
+
+	if _, err := service.SaveWindow(ctx, "order/main", []byte("namespace: order\nregion: All\n"), nil); err != nil {
+		t.Fatalf("SaveWindow() error = %v", err)
+	}
+	if actual := mustReadMetaFile(t, filepath.Join(root, "order", "main.yaml")); !strings.Contains(actual, "region: All") {
+		t.Fatalf("expected base window to be written without a target, got:\n%s", actual)
+	}
+	if actual := mustReadMetaFile(t, filepath.Join(root, "order", "ios", "phone", "main.yaml")); !strings.Contains(actual, "region: iPhone") {
+		t.Fatalf("expected other branches to be unchanged, got:\n%s", actual)
+	}
+
+	tablet := &TargetContext{Platform: "android", FormFactor: "tablet"}
+	if _, err := service.SaveWindow(ctx, "report/main", []byte("namespace: report\n"), tablet); err != nil {
+		t.Fatalf("SaveWindow() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "report", "main.yaml")); err != nil {
+		t.Fatalf("expected new window at the base path: %v", err)
+	}
+	if _, err := service.SaveWindow(ctx, "invoice/main", []byte("namespace: invoice\n"), tablet, WithFork()); err != nil {
+		t.Fatalf("SaveWindow() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "invoice", "android", "tablet", "main.yaml")); err != nil {
+		t.Fatalf("expected forked new window in the most specific branch: %v", err)
+	}
+}
+
+func TestSaveWindow_KeepsIndentWidth(t *testing.T) {
+	root := t.TempDir()
+	mustWriteMetaFile(t, filepath.Join(root, "order", "main.yaml"), "namespace: order\nview:\n    content:\n        title: Orders\n")
+	service := New(afs.New(), root)
+
+	if _, err := service.SaveWindow(context.Background(), "order/main", []byte("namespace: order\nview:\n  content:\n    title: All orders\n"), nil); err != nil {
+		t.Fatalf("SaveWindow() error = %v", err)
+	}
+	if actual := mustReadMetaFile(t, filepath.Join(root, "order", "main.yaml")); actual != "namespace: order\nview:\n    content:\n        title: All orders\n" {
+		t.Fatalf("expected four space indent to be kept, got:\n%s", actual)
+	}
+}
+
+func TestSaveContainer_ByIDPath(t *testing.T) {
+	root := t.TempDir()
+	mustWriteMetaFile(t, filepath.Join(root, "order", "main.yaml"), `namespace: order
+view:
+  content:
+    containers:
+      - id: orders
+        containers: $import(orders.yaml)
+      - id: summary
+        title: Summary
+`)
+	mustWriteMetaFile(t, filepath.Join(root, "order", "orders.yaml"), `- id: summary
+  title: Order summary # nested
+- id: lines
+  title: Lines
+`)
+	service := New(afs.New(), root)
+	ctx := context.Background()
+
+	if _, err := service.SaveContainer(ctx, "order/main", "orders/summary", []byte("id: summary\ntitle: Totals\n"), nil); err != nil {
+		t.Fatalf("SaveContainer() error = %v", err)
+	}
+	if actual := mustReadMetaFile(t, filepath.Join(root, "order", "orders.yaml")); !strings.Contains(actual, "title: Totals # nested") {
+		t.Fatalf("expected nested container to be updated, got:\n%s", actual)
+	}
+	if actual := mustReadMetaFile(t, filepath.Join(root, "order", "main.yaml")); !strings.Contains(actual, "title: Summary") {
+		t.Fatalf("expected top-level summary to be unchanged, got:\n%s", actual)
+	}
+
+	if _, err := service.SaveContainer(ctx, "order/main", "missing", []byte("id: missing\n"), nil); err == nil {
+		t.Fatalf("expected error for unknown container")
+	}
+	if _, err := service.SaveContainer(ctx, "order/main", "summary", []byte("id: summary\ntitle: 1\nwidth: wide\n"), nil); err == nil {
+		t.Fatalf("expected validation error for container")
+	}
+}
+
+func TestSaveContainer_RejectsImportWithArguments(t *testing.T) {
+	root := t.TempDir()
+	mustWriteMetaFile(t, filepath.Join(root, "order", "main.yaml"), `namespace: order
+view:
+  content:
+    containers:
+      - id: header
+        section: $import(section.yaml, title=Orders)
+`)
+	mustWriteMetaFile(t, filepath.Join(root, "order", "section.yaml"), "properties:\n  title: ${title}\n")
+	service := New(afs.New(), root)
+	ctx := context.Background()
+
+	if _, err := service.SaveContainer(ctx, "order/main", "header", []byte("id: header\nsection:\n  properties:\n    title: Orders\n"), nil); err != nil {
+		t.Fatalf("expected unchanged import to be kept, got %v", err)
+	}
+	if _, err := service.SaveContainer(ctx, "order/main", "header", []byte("id: header\nsection:\n  properties:\n    title: Returns\n"), nil); err == nil || !strings.Contains(err.Error(), "arguments") {
+		t.Fatalf("expected write-through to an import with arguments to fail, got %v", err)
+	}
+	if actual := mustReadMetaFile(t, filepath.Join(root, "order", "section.yaml")); actual != "properties:\n  title: ${title}\n" {
+		t.Fatalf("expected imported file to be unchanged, got:\n%s", actual)
+	}
+}
+
+func mustReadMetaFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", path, err)
+	}
+	return string(data)
+}
@@ -0,0 +1,175 @@
+package meta
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/viant/afs"
+	"github.com/viant/afs/object"
+	"github.com/viant/afs/option"
+	"github.com/viant/afs/storage"
+	"github.com/viant/afs/url"
+)
+
+// BundleScheme is the URL scheme MountFS serves fs.FS bundles under.
+const BundleScheme = "fsbundle"
+
+func init() {
+	afs.GetRegistry().Register(BundleScheme, func(options ...storage.Option) (storage.Manager, error) {
+		return &bundleManager{}, nil
+	})
+}
+
+// bundleOption carries the fs.FS BundleScheme URLs are read from.
+type bundleOption struct {
+	fs fs.FS
+}
+
+// MountFS returns the root URL of the tree under dir in bundle, e.g. an
+// embed.FS compiled into the host binary, and the storage option that serves
+// it. Pass both to New or NewWithRoots, so branch and $import resolution work
+// the same as for a directory on disk. Files are read from bundle on demand;
+// nothing is copied, so there is nothing to release.
+func MountFS(bundle fs.FS, dir string) (string, storage.Option, error) {
+	dir = bundlePath(dir)
+	info, err := fs.Stat(bundle, dir)
+	if err == nil && !info.IsDir() {
+		err = fmt.Errorf("not a directory")
+	}
+	if err != nil {
+		return "", nil, fmt.Errorf("open metadata bundle %q: %w", dir, err)
+	}
+	root := BundleScheme + "://localhost"
+	if dir != "." {
+		root = url.Join(root, dir)
+	}
+	return root, &bundleOption{fs: bundle}, nil
+}
+
+// NewWithFS creates a Service over the metadata tree under dir in bundle.
+// Overlays, e.g. an on-disk hotfix directory, are searched before the bundle
+// (see NewWithRoots); the bundle root is the last entry of Roots.
+func NewWithFS(ctx context.Context, storageService afs.Service, bundle fs.FS, dir string, overlays []string, options ...storage.Option) (*Service, error) {
+	root, bundleOption, err := MountFS(bundle, dir)
+	if err != nil {
+		return nil, err
+	}
+	roots := append(append([]string{}, overlays...), root)
+	return NewWithRoots(storageService, roots, append(append([]storage.Option{}, options...), bundleOption)...), nil
+}
+
+// bundlePath converts a bundle URL path or dir to an fs.FS path.
+func bundlePath(location string) string {
+	location = strings.Trim(path.Clean("/"+location), "/")
+	if location == "" {
+		return "."
+	}
+	return location
+}
+
+// bundleManager is a read-only afs storage manager over the fs.FS passed
+// with each call as a bundleOption.
+type bundleManager struct{}
+
+func (m *bundleManager) bundle(URL string, options []storage.Option) (fs.FS, string, error) {
+	var holder *bundleOption
+	if _, ok := option.Assign(options, &holder); !ok || holder == nil {
+		return nil, "", fmt.Errorf("%s: no metadata bundle configured", URL)
+	}
+	return holder.fs, bundlePath(url.Path(URL)), nil
+}
+
+func (m *bundleManager) List(ctx context.Context, URL string, options ...storage.Option) ([]storage.Object, error) {
+	bundle, name, err := m.bundle(URL, options)
+	if err != nil {
+		return nil, err
+	}
+	info, err := fs.Stat(bundle, name)
+	if err != nil {
+		return nil, err
+	}
+	result := []storage.Object{object.New(URL, info, nil)}
+	if !info.IsDir() {
+		return result, nil
+	}
+	entries, err := fs.ReadDir(bundle, name)
+	if err != nil {
+		return nil, err
+	}
+	match, page := option.GetListOptions(options)
+	for _, entry := range entries {
+		entryInfo, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		if !match(url.Path(URL), entryInfo) {
+			continue
+		}
+		if page.Increment(); page.ShallSkip() {
+			continue
+		}
+		result = append(result, object.New(url.Join(URL, entry.Name()), entryInfo, nil))
+		if page.HasReachedLimit() {
+			break
+		}
+	}
+	return result, nil
+}
+
+func (m *bundleManager) Object(ctx context.Context, URL string, options ...storage.Option) (storage.Object, error) {
+	bundle, name, err := m.bundle(URL, options)
+	if err != nil {
+		return nil, err
+	}
+	info, err := fs.Stat(bundle, name)
+	if err != nil {
+		return nil, err
+	}
+	return object.New(URL, info, nil), nil
+}
+
+func (m *bundleManager) Exists(ctx context.Context, URL string, options ...storage.Option) (bool, error) {
+	bundle, name, err := m.bundle(URL, options)
+	if err != nil {
+		return false, err
+	}
+	_, err = fs.Stat(bundle, name)
+	return err == nil, nil
+}
+
+func (m *bundleManager) Open(ctx context.Context, object storage.Object, options ...storage.Option) (io.ReadCloser, error) {
+	return m.OpenURL(ctx, object.URL(), options...)
+}
+
+func (m *bundleManager) OpenURL(ctx context.Context, URL string, options ...storage.Option) (io.ReadCloser, error) {
+	bundle, name, err := m.bundle(URL, options)
+	if err != nil {
+		return nil, err
+	}
+	return bundle.Open(name)
+}
+
+func (m *bundleManager) Upload(ctx context.Context, URL string, mode os.FileMode, reader io.Reader, options ...storage.Option) error {
+	return fmt.Errorf("%s: metadata bundles are read-only", URL)
+}
+
+func (m *bundleManager) Delete(ctx context.Context, URL string, options ...storage.Option) error {
+	return fmt.Errorf("%s: metadata bundles are read-only", URL)
+}
+
+func (m *bundleManager) Create(ctx context.Context, URL string, mode os.FileMode, isDir bool, options ...storage.Option) error {
+	return fmt.Errorf("%s: metadata bundles are read-only", URL)
+}
+
+func (m *bundleManager) Close() error {
+	return nil
+}
+
+func (m *bundleManager) Scheme() string {
+	return BundleScheme
+}
@@ -0,0 +1,83 @@
+package meta
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/viant/afs"
+	"github.com/viant/forge/backend/types"
+)
+
+func TestNewWithFS_ResolvesBranchesImportsAndOverlay(t *testing.T) {
+	bundle := fstest.MapFS{
+		"metadata/window/order/shared/main.yaml":  {Data: []byte("namespace: order\nview:\n  content:\n    table: $import(table.yaml)\n")},
+		"metadata/window/order/shared/table.yaml": {Data: []byte("id: shared\n")},
+		"metadata/window/order/web/table.yaml":    {Data: []byte("id: web\n")},
+	}
+	ctx := context.Background()
+	target := &TargetContext{Platform: "web", FormFactor: "desktop"}
+
+	service, err := NewWithFS(ctx, afs.New(), bundle, "metadata/window", nil)
+	if err != nil {
+		t.Fatalf("NewWithFS() error = %v", err)
+	}
+	if got := loadBundleTableID(t, service, target); got != "web" {
+		t.Fatalf("expected web branch import from bundle, got %q", got)
+	}
+
+	overlay := t.TempDir()
+	mustWriteMetaFile(t, filepath.Join(overlay, "order", "web", "table.yaml"), "id: hotfix\n")
+	service, err = NewWithFS(ctx, afs.New(), bundle, "metadata/window", []string{overlay})
+	if err != nil {
+		t.Fatalf("NewWithFS() error = %v", err)
+	}
+	if got := loadBundleTableID(t, service, target); got != "hotfix" {
+		t.Fatalf("expected on-disk overlay to shadow bundle, got %q", got)
+	}
+}
+
+func TestMountFS_MissingDirReportsError(t *testing.T) {
+	if _, _, err := MountFS(fstest.MapFS{}, "missing"); err == nil {
+		t.Fatalf("expected error for missing bundle dir")
+	}
+}
+
+func TestNewWithFS_ReadsBundleInPlace(t *testing.T) {
+	bundle := fstest.MapFS{"window/order.yaml": {Data: []byte("namespace: order\n")}}
+	service, err := NewWithFS(context.Background(), afs.New(), bundle, "window", nil)
+	if err != nil {
+		t.Fatalf("NewWithFS() error = %v", err)
+	}
+	if roots := service.Roots(); len(roots) != 1 || roots[0] != BundleScheme+"://localhost/window" {
+		t.Fatalf("expected the bundle root, got %v", roots)
+	}
+	bundle["window/order.yaml"] = &fstest.MapFile{Data: []byte("namespace: changed\n")}
+	window := &types.Window{}
+	if err := service.Load(context.Background(), "order.yaml", window); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if window.Namespace != "changed" {
+		t.Fatalf("expected the window to be read from the bundle, got %q", window.Namespace)
+	}
+}
+
+func loadBundleTableID(t *testing.T, service *Service, target *TargetContext) string {
+	t.Helper()
+	base, err := service.ResolveWindowBase(context.Background(), "order/main", target)
+	if err != nil {
+		t.Fatalf("ResolveWindowBase() error = %v", err)
+	}
+	var window struct {
+		View struct {
+			Content struct {
+				Table struct{ ID string } `yaml:"table"`
+			} `yaml:"content"`
+		} `yaml:"view"`
+	}
+	if err := service.LoadForTarget(context.Background(), base+".yaml", &window, target); err != nil {
+		t.Fatalf("LoadForTarget() error = %v", err)
+	}
+	return window.View.Content.Table.ID
+}
@@ -0,0 +1,369 @@
+package meta
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/viant/afs/file"
+	"github.com/viant/afs/url"
+	"gopkg.in/yaml.v3"
+)
+
+const defaultCacheTTL = 30 * time.Second
+
+// CacheOption configures the metadata cache.
+type CacheOption func(*cache)
+
+// WithCacheTTL sets how long cached entries are served before their source
+// files are revalidated. Entries fully covered by Watch never expire.
+func WithCacheTTL(ttl time.Duration) CacheOption {
+	return func(c *cache) {
+		if ttl > 0 {
+			c.ttl = ttl
+		}
+	}
+}
+
+// cache memoizes resolved YAML nodes and branch resolution per URL and
+// normalized TargetContext. Nodes are cloned on the way in and out because
+// callers mutate them (ApplyTarget, Decode).
+type cache struct {
+	ttl time.Duration
+
+	mu         sync.RWMutex
+	generation uint64
+	nodes      map[string]*nodeEntry
+	paths      map[string]*pathEntry
+	watchRoots []string
+	watched    bool
+	watcher    *fsnotify.Watcher
+}
+
+type nodeEntry struct {
+	node      *yaml.Node
+	sources   map[string]string
+	expiresAt time.Time
+}
+
+type pathEntry struct {
+	value     string
+	expiresAt time.Time
+}
+
+func newCache(options ...CacheOption) *cache {
+	result := &cache{ttl: defaultCacheTTL, nodes: map[string]*nodeEntry{}, paths: map[string]*pathEntry{}}
+	for _, option := range options {
+		if option != nil {
+			option(result)
+		}
+	}
+	return result
+}
+
+// EnableCache turns on memoization of resolved metadata and branch
+// resolution. Call Watch to invalidate entries on local file changes;
+// otherwise entries are revalidated against source modification time and
+// size once their TTL elapses.
+func (l *Service) EnableCache(options ...CacheOption) *Service {
+	l.cache = newCache(options...)
+	return l
+}
+
+// InvalidateCache drops every cached entry.
+func (l *Service) InvalidateCache() {
+	if l.cache == nil {
+		return
+	}
+	l.cache.mu.Lock()
+	l.cache.generation++
+	l.cache.nodes = map[string]*nodeEntry{}
+	l.cache.paths = map[string]*pathEntry{}
+	l.cache.mu.Unlock()
+}
+
+// Watch installs a recursive fsnotify watch over every file:// root and
+// invalidates the cache on every change. Other schemes rely on TTL
+// revalidation, so Watch is a no-op for them.
+func (l *Service) Watch(ctx context.Context) error {
+	if l.cache == nil {
+		return fmt.Errorf("metadata cache is not enabled")
+	}
+	var roots []string
+	for _, baseURL := range l.roots {
+		root, ok := localRoot(baseURL)
+		if !ok {
+			continue
+		}
+		if info, err := os.Stat(root); err != nil {
+			return fmt.Errorf("watch metadata root %q: %w", root, err)
+		} else if !info.IsDir() {
+			return fmt.Errorf("watch metadata root %q: not a directory", root)
+		}
+		roots = append(roots, root)
+	}
+	if len(roots) == 0 {
+		return nil
+	}
+	fileWatcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("create metadata watcher: %w", err)
+	}
+	for _, root := range roots {
+		if err = addRecursiveMetaWatch(fileWatcher, root); err != nil {
+			_ = fileWatcher.Close()
+			return fmt.Errorf("watch metadata root %q: %w", root, err)
+		}
+	}
+	l.cache.mu.Lock()
+	if l.cache.watcher != nil {
+		l.cache.mu.Unlock()
+		_ = fileWatcher.Close()
+		return fmt.Errorf("metadata watcher already started")
+	}
+	l.cache.watcher = fileWatcher
+	l.cache.watchRoots = roots
+	// A remote overlay root can gain a shadowing file unnoticed.
+	l.cache.watched = len(roots) == len(l.roots)
+	l.cache.mu.Unlock()
+	// Entries cached before the watch started may already be stale.
+	l.InvalidateCache()
+	go l.watchLoop(ctx, fileWatcher)
+	return nil
+}
+
+// Close stops the metadata watcher, if any.
+func (l *Service) Close() error {
+	if l.cache == nil {
+		return nil
+	}
+	l.cache.mu.Lock()
+	fileWatcher := l.cache.watcher
+	l.cache.watcher = nil
+	l.cache.watchRoots, l.cache.watched = nil, false
+	l.cache.mu.Unlock()
+	if fileWatcher == nil {
+		return nil
+	}
+	return fileWatcher.Close()
+}
+
+func (l *Service) watchLoop(ctx context.Context, fileWatcher *fsnotify.Watcher) {
+	defer func() {
+		l.cache.mu.Lock()
+		if l.cache.watcher == fileWatcher {
+			l.cache.watcher = nil
+			l.cache.watchRoots, l.cache.watched = nil, false
+		}
+		l.cache.mu.Unlock()
+		_ = fileWatcher.Close()
+		l.InvalidateCache()
+	}()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-fileWatcher.Events:
+			if !ok {
+				return
+			}
+			if event.Has(fsnotify.Create) {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					_ = addRecursiveMetaWatch(fileWatcher, event.Name)
+				}
+			}
+			l.InvalidateCache()
+		case _, ok := <-fileWatcher.Errors:
+			if !ok {
+				return
+			}
+			// A dropped event may hide a change, so fall back to a cold cache.
+			l.InvalidateCache()
+		}
+	}
+}
+
+func (l *Service) cachedNode(ctx context.Context, key string) (*yaml.Node, bool) {
+	if l.cache == nil {
+		return nil, false
+	}
+	l.cache.mu.RLock()
+	entry, ok := l.cache.nodes[key]
+	expired := ok && !entry.expiresAt.IsZero() && time.Now().After(entry.expiresAt)
+	l.cache.mu.RUnlock()
+	if !ok {
+		return nil, false
+	}
+	if expired {
+		for source, fingerprint := range entry.sources {
+			if current, err := l.fingerprint(ctx, source); err != nil || current != fingerprint || l.overlayURL(ctx, source) != source {
+				l.cache.mu.Lock()
+				if l.cache.nodes[key] == entry {
+					delete(l.cache.nodes, key)
+				}
+				l.cache.mu.Unlock()
+				return nil, false
+			}
+		}
+		l.cache.mu.Lock()
+		entry.expiresAt = time.Now().Add(l.cache.ttl)
+		l.cache.mu.Unlock()
+	}
+	return cloneNode(entry.node), true
+}
+
+// cacheGeneration identifies the cache state a load started from, so a load
+// racing with an invalidation never stores a stale node.
+func (l *Service) cacheGeneration() uint64 {
+	if l.cache == nil {
+		return 0
+	}
+	l.cache.mu.RLock()
+	defer l.cache.mu.RUnlock()
+	return l.cache.generation
+}
+
+func (l *Service) putNode(ctx context.Context, key string, generation uint64, node *yaml.Node, sources []string) {
+	if l.cache == nil {
+		return
+	}
+	entry := &nodeEntry{node: cloneNode(node), sources: map[string]string{}}
+	l.cache.mu.RLock()
+	watchRoots, covered := l.cache.watchRoots, l.cache.watched
+	l.cache.mu.RUnlock()
+	for _, source := range sources {
+		fingerprint, err := l.fingerprint(ctx, source)
+		if err != nil {
+			return
+		}
+		entry.sources[source] = fingerprint
+		if covered && !withinAnyRoot(watchRoots, source) {
+			covered = false
+		}
+	}
+	if !covered {
+		entry.expiresAt = time.Now().Add(l.cache.ttl)
+	}
+	l.cache.mu.Lock()
+	if l.cache.generation == generation {
+		l.cache.nodes[key] = entry
+	}
+	l.cache.mu.Unlock()
+}
+
+func (l *Service) cachedPath(key string) (string, bool) {
+	if l.cache == nil {
+		return "", false
+	}
+	l.cache.mu.RLock()
+	defer l.cache.mu.RUnlock()
+	entry, ok := l.cache.paths[key]
+	if !ok || (!entry.expiresAt.IsZero() && time.Now().After(entry.expiresAt)) {
+		return "", false
+	}
+	return entry.value, true
+}
+
+func (l *Service) putPath(key, value string) {
+	if l.cache == nil {
+		return
+	}
+	l.cache.mu.Lock()
+	defer l.cache.mu.Unlock()
+	entry := &pathEntry{value: value}
+	if !l.cache.watched {
+		entry.expiresAt = time.Now().Add(l.cache.ttl)
+	}
+	l.cache.paths[key] = entry
+}
+
+// fingerprint identifies a source revision by modification time and size,
+// which afs exposes for every scheme.
+func (l *Service) fingerprint(ctx context.Context, URL string) (string, error) {
+	object, err := l.fs.Object(ctx, URL, l.options...)
+	if err != nil {
+		return "", err
+	}
+	return strconv.FormatInt(object.ModTime().UnixNano(), 36) + "-" + strconv.FormatInt(object.Size(), 36), nil
+}
+
+// cacheKey combines a location with a normalized TargetContext.
+func cacheKey(kind, location string, target *TargetContext) string {
+	return kind + "|" + location + "|" + targetCacheKey(target)
+}
+
+func targetCacheKey(target *TargetContext) string {
+	if target == nil {
+		return "-"
+	}
+	capabilities := make([]string, 0, len(target.Capabilities))
+	for _, capability := range target.Capabilities {
+		if trimmed := strings.TrimSpace(capability); trimmed != "" {
+			capabilities = append(capabilities, trimmed)
+		}
+	}
+	sort.Strings(capabilities)
+	return strings.Join([]string{
+		strings.TrimSpace(target.Platform),
+		strings.TrimSpace(target.FormFactor),
+		strings.TrimSpace(target.Surface),
+		strings.Join(uniqueStrings(capabilities), ","),
+	}, "/")
+}
+
+// localRoot returns the absolute local directory for a file:// or
+// scheme-less base URL.
+func localRoot(baseURL string) (string, bool) {
+	if baseURL == "" || url.Scheme(baseURL, file.Scheme) != file.Scheme {
+		return "", false
+	}
+	root, err := filepath.Abs(filepath.FromSlash(url.Path(baseURL)))
+	if err != nil {
+		return "", false
+	}
+	return root, true
+}
+
+func withinAnyRoot(roots []string, URL string) bool {
+	for _, root := range roots {
+		if withinRoot(root, URL) {
+			return true
+		}
+	}
+	return false
+}
+
+func withinRoot(root, URL string) bool {
+	candidate, ok := localRoot(URL)
+	if !ok {
+		return false
+	}
+	rel, err := filepath.Rel(root, candidate)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) && !filepath.IsAbs(rel)
+}
+
+func addRecursiveMetaWatch(watcher *fsnotify.Watcher, root string) error {
+	return filepath.WalkDir(root, func(path string, entry fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		if entry.Type()&os.ModeSymlink != 0 {
+			return nil
+		}
+		if entry.IsDir() {
+			return watcher.Add(path)
+		}
+		return nil
+	})
+}
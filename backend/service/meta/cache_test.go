@@ -0,0 +1,97 @@
+package meta
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/viant/afs"
+)
+
+func TestCache_WatchInvalidatesOnFileChange(t *testing.T) {
+	root := t.TempDir()
+	mainPath := filepath.Join(root, "window", "order", "shared", "main.yaml")
+	tablePath := filepath.Join(root, "window", "order", "shared", "table.yaml")
+	mustWriteMetaFile(t, mainPath, "namespace: order\nview:\n  content:\n    table: $import(table.yaml)\n")
+	mustWriteMetaFile(t, tablePath, "id: v1\n")
+
+	service := New(afs.New(), filepath.Join(root, "window")).EnableCache(WithCacheTTL(time.Hour))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := service.Watch(ctx); err != nil {
+		t.Fatalf("Watch() error = %v", err)
+	}
+	defer service.Close()
+	target := &TargetContext{Platform: "web", FormFactor: "desktop"}
+
+	base, err := service.ResolveWindowBase(ctx, "order/main", target)
+	if err != nil || base != "order/shared/main" {
+		t.Fatalf("unexpected base %q err=%v", base, err)
+	}
+	if got := loadFixtureTableID(t, service, target); got != "v1" {
+		t.Fatalf("expected v1, got %q", got)
+	}
+
+	mustWriteMetaFile(t, filepath.Join(root, "window", "order", "web", "main.yaml"), "namespace: web\n")
+	mustWriteMetaFile(t, tablePath, "id: v2\n")
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		base, _ = service.ResolveWindowBase(ctx, "order/main", target)
+		if base == "order/web/main" && loadFixtureTableID(t, service, target) == "v2" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for cache invalidation, base=%q", base)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestCache_RevalidatesExpiredEntries(t *testing.T) {
+	root := t.TempDir()
+	tablePath := filepath.Join(root, "window", "order", "shared", "table.yaml")
+	mustWriteMetaFile(t, filepath.Join(root, "window", "order", "shared", "main.yaml"), "namespace: order\nview:\n  content:\n    table: $import(table.yaml)\n")
+	mustWriteMetaFile(t, tablePath, "id: v1\n")
+
+	service := New(afs.New(), filepath.Join(root, "window")).EnableCache(WithCacheTTL(time.Hour))
+	target := &TargetContext{Platform: "web"}
+	if got := loadFixtureTableID(t, service, target); got != "v1" {
+		t.Fatalf("expected v1, got %q", got)
+	}
+	mustWriteMetaFile(t, tablePath, "id: v2-longer\n")
+	if got := loadFixtureTableID(t, service, target); got != "v1" {
+		t.Fatalf("expected cached v1 before TTL, got %q", got)
+	}
+
+	service.cache.mu.Lock()
+	for _, entry := range service.cache.nodes {
+		entry.expiresAt = time.Now().Add(-time.Second)
+	}
+	service.cache.mu.Unlock()
+	if got := loadFixtureTableID(t, service, target); got != "v2-longer" {
+		t.Fatalf("expected revalidated v2-longer, got %q", got)
+	}
+}
+
+func TestTargetCacheKey_NormalizesCapabilities(t *testing.T) {
+	left := targetCacheKey(&TargetContext{Platform: "ios", Capabilities: []string{"chart", " lookup", "chart"}})
+	right := targetCacheKey(&TargetContext{Platform: "ios", Capabilities: []string{"lookup", "chart"}})
+	if left != right {
+		t.Fatalf("expected equal keys, got %q and %q", left, right)
+	}
+	if targetCacheKey(nil) == targetCacheKey(&TargetContext{}) {
+		t.Fatalf("expected nil target to differ from empty target")
+	}
+}
+
+func loadFixtureTableID(t *testing.T, service *Service, target *TargetContext) string {
+	t.Helper()
+	var decoded windowFixture
+	if err := service.LoadWithTarget(context.Background(), "order/shared/main.yaml", &decoded, target); err != nil {
+		t.Fatalf("unexpected load error: %v", err)
+	}
+	table, _ := decoded.View.Content["table"].(map[string]any)
+	id, _ := table["id"].(string)
+	return id
+}
@@ -0,0 +1,196 @@
+package meta
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	requiresCapabilitiesKey = "requiresCapabilities"
+	fallbackKey             = "fallback"
+)
+
+// resolveCapabilities substitutes a container whose requiresCapabilities
+// the target lacks with its fallback, repeating while the fallback itself
+// requires missing capabilities. The fallback inherits the container id
+// when it declares none, so bindings keyed by id keep working. It returns
+// false when no renderable alternative remains.
+func resolveCapabilities(node *yaml.Node, target *TargetContext) bool {
+	for {
+		required := mappingValue(node, requiresCapabilitiesKey)
+		if required == nil {
+			return true
+		}
+		var capabilities []string
+		if err := required.Decode(&capabilities); err != nil {
+			// Not a capability list; leave the node for validation to report.
+			return true
+		}
+		if hasCapabilities(target, capabilities) {
+			removeMappingKey(node, requiresCapabilitiesKey)
+			removeMappingKey(node, fallbackKey)
+			return true
+		}
+		fallback := mappingValue(node, fallbackKey)
+		if fallback == nil || fallback.Kind != yaml.MappingNode {
+			return false
+		}
+		id := mappingValue(node, "id")
+		node.Content = fallback.Content
+		if id != nil && mappingValue(node, "id") == nil {
+			node.Content = append([]*yaml.Node{{Kind: yaml.ScalarNode, Tag: "!!str", Value: "id"}, id}, node.Content...)
+		}
+		if !resolveTargetMapping(node, target) {
+			return false
+		}
+	}
+}
+
+func hasCapabilities(target *TargetContext, capabilities []string) bool {
+	for _, capability := range capabilities {
+		if capability = strings.TrimSpace(capability); capability == "" {
+			continue
+		}
+		if target == nil || !containsString(target.Capabilities, capability) {
+			return false
+		}
+	}
+	return true
+}
+
+// MatchesVersion reports whether version satisfies constraint, a list of
+// comparisons separated by spaces or commas that must all hold, e.g.
+// ">=2.4 <3", "> 2.4" or "=2.4.1". Operators are =, ==, !=, >, >=, <, <=,
+// ^ (at least the bound, same major version) and ~ (at least the bound,
+// same major and, when given, minor version); a bare version means =. Versions compare
+// numerically by dotted segment, missing segments count as zero and
+// pre-release or build suffixes are ignored. An empty version or malformed
+// constraint never matches.
+func MatchesVersion(constraint, version string) bool {
+	current, ok := parseVersion(version)
+	if !ok {
+		return false
+	}
+	comparisons, err := parseConstraint(constraint)
+	if err != nil {
+		return false
+	}
+	for _, comparison := range comparisons {
+		if !comparison.matches(current) {
+			return false
+		}
+	}
+	return true
+}
+
+// versionOperators lists the supported operators, longest first so a
+// prefix match picks ">=" over ">".
+var versionOperators = []string{">=", "<=", "!=", "==", ">", "<", "=", "^", "~"}
+
+// versionComparison is a single operator and bound of a version constraint.
+type versionComparison struct {
+	operator string
+	bound    []int
+}
+
+// parseConstraint parses constraint into its comparisons; see MatchesVersion.
+func parseConstraint(constraint string) ([]versionComparison, error) {
+	fields := strings.FieldsFunc(constraint, func(r rune) bool { return r == ' ' || r == ',' })
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("empty version constraint")
+	}
+	var result []versionComparison
+	for i := 0; i < len(fields); i++ {
+		field := fields[i]
+		if containsString(versionOperators, field) && i+1 < len(fields) {
+			i++
+			field += fields[i]
+		}
+		operator := ""
+		for _, candidate := range versionOperators {
+			if strings.HasPrefix(field, candidate) {
+				operator = candidate
+				break
+			}
+		}
+		bound, ok := parseVersion(field[len(operator):])
+		if !ok {
+			return nil, fmt.Errorf("invalid version constraint %q", field)
+		}
+		result = append(result, versionComparison{operator: operator, bound: bound})
+	}
+	return result, nil
+}
+
+func (c versionComparison) matches(version []int) bool {
+	comparison := compareVersions(version, c.bound)
+	switch c.operator {
+	case "", "=", "==":
+		return comparison == 0
+	case "!=":
+		return comparison != 0
+	case ">":
+		return comparison > 0
+	case ">=":
+		return comparison >= 0
+	case "<":
+		return comparison < 0
+	case "<=":
+		return comparison <= 0
+	case "^":
+		return comparison >= 0 && versionSegment(version, 0) == versionSegment(c.bound, 0)
+	case "~":
+		return comparison >= 0 && versionSegment(version, 0) == versionSegment(c.bound, 0) && (len(c.bound) < 2 || versionSegment(version, 1) == c.bound[1])
+	}
+	return false
+}
+
+// versionSegment returns the dotted segment at index, zero when missing.
+func versionSegment(version []int, index int) int {
+	if index < len(version) {
+		return version[index]
+	}
+	return 0
+}
+
+func parseVersion(version string) ([]int, bool) {
+	version = strings.TrimPrefix(strings.TrimSpace(version), "v")
+	if index := strings.IndexAny(version, "-+"); index != -1 {
+		version = version[:index]
+	}
+	if version == "" {
+		return nil, false
+	}
+	parts := strings.Split(version, ".")
+	result := make([]int, len(parts))
+	for i, part := range parts {
+		value, err := strconv.Atoi(part)
+		if err != nil || value < 0 {
+			return nil, false
+		}
+		result[i] = value
+	}
+	return result, true
+}
+
+func compareVersions(left, right []int) int {
+	for i := 0; i < len(left) || i < len(right); i++ {
+		var l, r int
+		if i < len(left) {
+			l = left[i]
+		}
+		if i < len(right) {
+			r = right[i]
+		}
+		if l != r {
+			if l < r {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
@@ -0,0 +1,99 @@
+package meta
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/viant/afs"
+	"github.com/viant/forge/backend/types"
+)
+
+func TestMatchesVersion(t *testing.T) {
+	testCases := []struct {
+		constraint string
+		version    string
+		expected   bool
+	}{
+		{constraint: ">=2.4 <3", version: "2.4.0", expected: true},
+		{constraint: ">=2.4 <3", version: "2.10", expected: true},
+		{constraint: ">=2.4 <3", version: "2.3.9", expected: false},
+		{constraint: ">=2.4, <3", version: "3.0.0", expected: false},
+		{constraint: "<2.4", version: "v2.3.1-beta+7", expected: true},
+		{constraint: "2.4", version: "2.4.0", expected: true},
+		{constraint: "!=2.4.1", version: "2.4.1", expected: false},
+		{constraint: ">=2.4", version: "", expected: false},
+		{constraint: "~2.4", version: "2.4", expected: true},
+		{constraint: "~2.4", version: "2.4.9", expected: true},
+		{constraint: "~2.4", version: "2.5", expected: false},
+		{constraint: "~2", version: "2.7", expected: true},
+		{constraint: "^2.4", version: "2.9.1", expected: true},
+		{constraint: "^2.4", version: "2.3", expected: false},
+		{constraint: "^2.4", version: "3.0", expected: false},
+		{constraint: "=>1.2", version: "1.2", expected: false},
+		{constraint: "!1.0", version: "2.0", expected: false},
+		{constraint: ">=x", version: "2.4", expected: false},
+		{constraint: "> 2.4", version: "2.5", expected: true},
+		{constraint: ">= 2.4, < 3", version: "3.1", expected: false},
+		{constraint: ">", version: "2.4", expected: false},
+	}
+	for _, testCase := range testCases {
+		if actual := MatchesVersion(testCase.constraint, testCase.version); actual != testCase.expected {
+			t.Fatalf("MatchesVersion(%q, %q): expected %v, got %v", testCase.constraint, testCase.version, testCase.expected, actual)
+		}
+	}
+}
+
+func TestLoadForTarget_ResolvesCapabilityFallbacks(t *testing.T) {
+	root := t.TempDir()
+	mustWriteMetaFile(t, filepath.Join(root, "order.yaml"), `namespace: order
+view:
+  content:
+    containers:
+      - id: trend
+        requiresCapabilities: [chart]
+        chart:
+          type: line
+        fallback:
+          requiresCapabilities: [table]
+          table:
+            columns:
+              - id: day
+          fallback:
+            title: Trend unavailable
+      - id: map
+        requiresCapabilities: [map]
+        title: Map
+      - id: banner
+        target: {appVersion: ">=2.4"}
+        title: New banner
+`)
+	service := New(afs.New(), root)
+	load := func(target *TargetContext) *types.Window {
+		window := &types.Window{}
+		if err := service.LoadForTarget(context.Background(), "order.yaml", window, target); err != nil {
+			t.Fatalf("LoadForTarget() error = %v", err)
+		}
+		return window
+	}
+
+	window := load(&TargetContext{Platform: "ios", Capabilities: []string{"chart", "map"}, AppVersion: "2.4.1"})
+	containers := window.View.Content.Containers
+	if len(containers) != 3 || containers[0].Chart == nil || containers[0].Fallback != nil || containers[0].RequiresCapabilities != nil {
+		t.Fatalf("expected capable client to get the chart without fallback, got %+v", containers)
+	}
+
+	window = load(&TargetContext{Platform: "ios", Capabilities: []string{"table"}, AppVersion: "2.3"})
+	containers = window.View.Content.Containers
+	if len(containers) != 1 {
+		t.Fatalf("expected map and banner to be dropped, got %d containers", len(containers))
+	}
+	if trend := containers[0]; trend.ID != "trend" || trend.Chart != nil || trend.Table == nil || trend.Fallback != nil {
+		t.Fatalf("expected table fallback keeping the container id, got %+v", trend)
+	}
+
+	window = load(&TargetContext{Platform: "ios"})
+	if trend := window.View.Content.Containers[0]; trend.ID != "trend" || trend.Table != nil || trend.Title != "Trend unavailable" {
+		t.Fatalf("expected nested fallback, got %+v", trend)
+	}
+}
@@ -0,0 +1,260 @@
+package meta
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+const defaultChangeDebounce = 250 * time.Millisecond
+
+// WindowChange reports a window whose resolved metadata depends on changed
+// files. Targets lists the target contexts affected ("web/desktop"); Files
+// holds the changed files, relative to their metadata root when possible.
+type WindowChange struct {
+	Key     string   `json:"key"`
+	Targets []string `json:"targets,omitempty"`
+	Files   []string `json:"files"`
+}
+
+// AffectedWindows returns the windows among keys that depend on files for
+// any of targets. A window depends on every file in its import graph, its
+// .js asset, and any file in its own folder, so a newly added branch file
+// that would change resolution is reported too.
+func (l *Service) AffectedWindows(ctx context.Context, files []string, keys []string, targets []*TargetContext) []WindowChange {
+	if len(targets) == 0 {
+		targets = []*TargetContext{nil}
+	}
+	changed := map[string]string{}
+	for _, file := range files {
+		changed[overlayKey(file)] = file
+	}
+	var result []WindowChange
+	for _, key := range keys {
+		change := WindowChange{Key: key}
+		matched := map[string]bool{}
+		affected := false
+		for _, target := range targets {
+			hit := false
+			for _, dependency := range l.windowFiles(ctx, key, target) {
+				if file, ok := changed[overlayKey(dependency)]; ok {
+					matched[file], hit = true, true
+				}
+			}
+			for normalized, file := range changed {
+				if rel, ok := l.rootRelative(normalized); ok && windowOwns(key, rel) {
+					matched[file], hit = true, true
+				}
+			}
+			if !hit {
+				continue
+			}
+			affected = true
+			if name := target.String(); name != "" {
+				change.Targets = append(change.Targets, name)
+			}
+		}
+		if !affected {
+			continue
+		}
+		for file := range matched {
+			if rel, ok := l.rootRelative(file); ok && rel != "" {
+				file = rel
+			}
+			change.Files = append(change.Files, file)
+		}
+		sort.Strings(change.Files)
+		result = append(result, change)
+	}
+	return result
+}
+
+// windowFiles lists the files window key resolves from for target.
+func (l *Service) windowFiles(ctx context.Context, key string, target *TargetContext) []string {
+	base, err := l.ResolveWindowBase(ctx, path.Join(key, "main"), target)
+	if err != nil {
+		if base, err = l.ResolveWindowBase(ctx, key, target); err != nil {
+			return nil
+		}
+	}
+	var result []string
+	if graph, err := l.ImportGraph(ctx, base+".yaml", target); err == nil {
+		result = append(result, graph.Files...)
+	}
+	if asset, err := l.ResolveWindowAsset(ctx, base, ".js", target); err == nil {
+		result = append(result, l.overlayURL(ctx, l.getURL(asset)))
+	}
+	return result
+}
+
+// windowOwns reports whether rel sits in the folder of window key or is its
+// single-file definition.
+func windowOwns(key, rel string) bool {
+	if rel == key+".yaml" || rel == key+".js" {
+		return true
+	}
+	dir := path.Dir(rel)
+	return dir != "." && WindowKey(dir) == key
+}
+
+// ChangeWatchOption configures a ChangeWatcher.
+type ChangeWatchOption func(*ChangeWatcher)
+
+// WithChangeDebounce sets how long the watcher waits for more events before
+// reporting a batch.
+func WithChangeDebounce(delay time.Duration) ChangeWatchOption {
+	return func(watcher *ChangeWatcher) {
+		if delay > 0 {
+			watcher.debounce = delay
+		}
+	}
+}
+
+// ChangeWatcher reports batches of changed files under the local roots of a
+// Service, so callers can map them to windows with AffectedWindows.
+type ChangeWatcher struct {
+	service  *Service
+	debounce time.Duration
+
+	mu      sync.Mutex
+	watcher *fsnotify.Watcher
+}
+
+func NewChangeWatcher(service *Service, options ...ChangeWatchOption) *ChangeWatcher {
+	result := &ChangeWatcher{service: service, debounce: defaultChangeDebounce}
+	for _, option := range options {
+		if option != nil {
+			option(result)
+		}
+	}
+	return result
+}
+
+// Start begins recursive observation of every file:// root and returns after
+// watches are installed. onChange receives the changed file paths of each
+// debounced batch, or a watch error. The service cache is invalidated before
+// each batch is reported.
+func (w *ChangeWatcher) Start(ctx context.Context, onChange func(files []string, err error)) error {
+	var roots []string
+	for _, baseURL := range w.service.roots {
+		if root, ok := localRoot(baseURL); ok {
+			roots = append(roots, root)
+		}
+	}
+	if len(roots) == 0 {
+		return fmt.Errorf("watch metadata: no local metadata root")
+	}
+	fileWatcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("create metadata watcher: %w", err)
+	}
+	for _, root := range roots {
+		if err = addRecursiveMetaWatch(fileWatcher, root); err != nil {
+			_ = fileWatcher.Close()
+			return fmt.Errorf("watch metadata root %q: %w", root, err)
+		}
+	}
+	w.mu.Lock()
+	if w.watcher != nil {
+		w.mu.Unlock()
+		_ = fileWatcher.Close()
+		return fmt.Errorf("metadata change watcher already started")
+	}
+	w.watcher = fileWatcher
+	w.mu.Unlock()
+
+	go w.loop(ctx, fileWatcher, onChange)
+	return nil
+}
+
+func (w *ChangeWatcher) Close() error {
+	w.mu.Lock()
+	fileWatcher := w.watcher
+	w.watcher = nil
+	w.mu.Unlock()
+	if fileWatcher == nil {
+		return nil
+	}
+	return fileWatcher.Close()
+}
+
+func (w *ChangeWatcher) loop(ctx context.Context, fileWatcher *fsnotify.Watcher, onChange func([]string, error)) {
+	defer func() {
+		w.mu.Lock()
+		if w.watcher == fileWatcher {
+			w.watcher = nil
+		}
+		w.mu.Unlock()
+		_ = fileWatcher.Close()
+	}()
+
+	timer := time.NewTimer(time.Hour)
+	if !timer.Stop() {
+		<-timer.C
+	}
+	pending := map[string]bool{}
+	schedule := func(name string) {
+		if len(pending) > 0 && !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(w.debounce)
+		pending[name] = true
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-fileWatcher.Events:
+			if !ok {
+				return
+			}
+			if event.Has(fsnotify.Create) {
+				if info, statErr := os.Stat(event.Name); statErr == nil && info.IsDir() {
+					_ = addRecursiveMetaWatch(fileWatcher, event.Name)
+					continue
+				}
+			}
+			if isMetaFile(event.Name) && event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Remove|fsnotify.Rename) != 0 {
+				schedule(event.Name)
+			}
+		case <-timer.C:
+			files := make([]string, 0, len(pending))
+			for name := range pending {
+				files = append(files, name)
+			}
+			pending = map[string]bool{}
+			sort.Strings(files)
+			w.service.InvalidateCache()
+			if onChange != nil {
+				onChange(files, nil)
+			}
+		case watchErr, ok := <-fileWatcher.Errors:
+			if !ok {
+				return
+			}
+			if onChange != nil {
+				onChange(nil, fmt.Errorf("metadata watcher: %w", watchErr))
+			}
+		}
+	}
+}
+
+func isMetaFile(filename string) bool {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".yaml", ".yml", ".js":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,77 @@
+package meta
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/viant/afs"
+)
+
+func TestAffectedWindows_MapsFilesThroughImportGraph(t *testing.T) {
+	root := t.TempDir()
+	mustWriteMetaFile(t, filepath.Join(root, "common", "table.yaml"), "id: table\n")
+	mustWriteMetaFile(t, filepath.Join(root, "order", "shared", "main.yaml"), "namespace: order\nview:\n  content:\n    table: $import(../../common/table.yaml)\n")
+	mustWriteMetaFile(t, filepath.Join(root, "order", "web", "main.yaml"), "namespace: order\nview:\n  content:\n    table: $import(../../common/table.yaml)\n    title: $import(title.yaml)\n")
+	mustWriteMetaFile(t, filepath.Join(root, "order", "web", "title.yaml"), "web\n")
+	mustWriteMetaFile(t, filepath.Join(root, "customer", "shared", "main.yaml"), "namespace: customer\n")
+	mustWriteMetaFile(t, filepath.Join(root, "invoice.yaml"), "namespace: invoice\nview:\n  content:\n    table: $import(common/table.yaml)\n")
+
+	service := New(afs.New(), root)
+	keys := []string{"customer", "invoice", "order"}
+	web := &TargetContext{Platform: "web", FormFactor: "desktop"}
+	android := &TargetContext{Platform: "android", FormFactor: "phone"}
+	ctx := context.Background()
+
+	changes := service.AffectedWindows(ctx, []string{filepath.Join(root, "common", "table.yaml")}, keys, []*TargetContext{web, android})
+	expected := []WindowChange{
+		{Key: "invoice", Targets: []string{"web/desktop", "android/phone"}, Files: []string{"common/table.yaml"}},
+		{Key: "order", Targets: []string{"web/desktop", "android/phone"}, Files: []string{"common/table.yaml"}},
+	}
+	if !reflect.DeepEqual(changes, expected) {
+		t.Fatalf("expected %+v, got %+v", expected, changes)
+	}
+
+	changes = service.AffectedWindows(ctx, []string{filepath.Join(root, "order", "web", "title.yaml")}, keys, []*TargetContext{web, android})
+	// Folder ownership reports the window for every target, since a new
+	// branch file can change resolution for any of them.
+	if len(changes) != 1 || changes[0].Key != "order" {
+		t.Fatalf("expected only order to change, got %+v", changes)
+	}
+
+	changes = service.AffectedWindows(ctx, []string{filepath.Join(root, "unrelated.txt")}, keys, []*TargetContext{web})
+	if len(changes) != 0 {
+		t.Fatalf("expected no changes, got %+v", changes)
+	}
+}
+
+func TestChangeWatcher_ReportsDebouncedBatches(t *testing.T) {
+	root := t.TempDir()
+	mustWriteMetaFile(t, filepath.Join(root, "order", "shared", "main.yaml"), "namespace: order\n")
+	watcher := NewChangeWatcher(New(afs.New(), root), WithChangeDebounce(20*time.Millisecond))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	batches := make(chan []string, 4)
+	if err := watcher.Start(ctx, func(files []string, err error) {
+		if err == nil {
+			batches <- files
+		}
+	}); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer watcher.Close()
+
+	changed := filepath.Join(root, "order", "shared", "main.yaml")
+	mustWriteMetaFile(t, changed, "namespace: order2\n")
+	mustWriteMetaFile(t, filepath.Join(root, "order", "notes.txt"), "ignored\n")
+	select {
+	case files := <-batches:
+		if !reflect.DeepEqual(files, []string{changed}) {
+			t.Fatalf("expected %v, got %v", []string{changed}, files)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for change batch")
+	}
+}
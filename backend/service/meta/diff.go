@@ -0,0 +1,207 @@
+package meta
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/viant/forge/backend/types"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	DiffAdded   = "added"
+	DiffRemoved = "removed"
+	DiffChanged = "changed"
+)
+
+// WindowDiff is the structural difference between a window resolved for two
+// targets. Added nodes exist only for Right, removed ones only for Left.
+type WindowDiff struct {
+	Key      string       `json:"key"`
+	Left     string       `json:"left"`
+	Right    string       `json:"right"`
+	LeftURL  string       `json:"leftUrl"`
+	RightURL string       `json:"rightUrl"`
+	Changes  []DiffChange `json:"changes,omitempty"`
+}
+
+// DiffChange is one differing node. Sequence items with an id are matched by
+// it and addressed as [id=value] in YAMLPath, others by position. Sources
+// name the file each side's node was read from.
+type DiffChange struct {
+	Kind        string      `json:"kind"`
+	YAMLPath    string      `json:"yamlPath"`
+	Left        interface{} `json:"left,omitempty"`
+	Right       interface{} `json:"right,omitempty"`
+	LeftSource  string      `json:"leftSource,omitempty"`
+	RightSource string      `json:"rightSource,omitempty"`
+}
+
+// DiffWindow resolves window key for left and right the way LoadForTarget
+// does, including target matching, overrides, access rules and
+// localization, and compares the results. The window .js asset is not
+// compared.
+func (l *Service) DiffWindow(ctx context.Context, key string, left, right *TargetContext) (*WindowDiff, error) {
+	leftSide, err := l.diffSide(ctx, key, left)
+	if err != nil {
+		return nil, err
+	}
+	rightSide, err := l.diffSide(ctx, key, right)
+	if err != nil {
+		return nil, err
+	}
+	result := &WindowDiff{Key: key, Left: left.String(), Right: right.String(), LeftURL: leftSide.URL, RightURL: rightSide.URL}
+	differ := &windowDiffer{left: leftSide, right: rightSide}
+	differ.compare(leftSide.node, rightSide.node, "$", leftSide.URL, rightSide.URL)
+	result.Changes = differ.changes
+	return result, nil
+}
+
+// diffSide is one window resolved for one target.
+type diffSide struct {
+	URL     string
+	node    *yaml.Node
+	origins map[*yaml.Node]string
+}
+
+func (l *Service) diffSide(ctx context.Context, key string, target *TargetContext) (*diffSide, error) {
+	target, err := l.withFlags(ctx, target)
+	if err != nil {
+		return nil, err
+	}
+	base, err := l.ResolveWindowKey(ctx, key, target)
+	if err != nil {
+		return nil, err
+	}
+	URL := l.getURL(base + ".yaml")
+	session := newLoadSession(target)
+	node, err := l.resolveNode(ctx, URL, session)
+	if err != nil {
+		return nil, err
+	}
+	ApplyTarget(node, target)
+	ApplyAccess(node, &types.Window{}, target.caller())
+	if err := l.Localize(ctx, node, target); err != nil {
+		return nil, err
+	}
+	return &diffSide{URL: session.root(URL), node: getContentNode(node), origins: session.origins}, nil
+}
+
+type windowDiffer struct {
+	left, right *diffSide
+	changes     []DiffChange
+}
+
+// compare walks both nodes, inheriting each side's source from the nearest
+// imported ancestor.
+func (d *windowDiffer) compare(left, right *yaml.Node, path, leftSource, rightSource string) {
+	left, right = resolveAlias(left), resolveAlias(right)
+	if origin, ok := d.left.origins[left]; ok {
+		leftSource = origin
+	}
+	if origin, ok := d.right.origins[right]; ok {
+		rightSource = origin
+	}
+	if left.Kind != right.Kind || left.Kind == yaml.ScalarNode {
+		if !equalNodes(left, right) {
+			d.add(DiffChanged, path, left, right, leftSource, rightSource)
+		}
+		return
+	}
+	switch left.Kind {
+	case yaml.MappingNode:
+		var keys []string
+		for i := 0; i+1 < len(left.Content); i += 2 {
+			keys = append(keys, left.Content[i].Value)
+		}
+		for i := 0; i+1 < len(right.Content); i += 2 {
+			if mappingValue(left, right.Content[i].Value) == nil {
+				keys = append(keys, right.Content[i].Value)
+			}
+		}
+		for _, key := range keys {
+			d.pair(mappingValue(left, key), mappingValue(right, key), path+"."+key, leftSource, rightSource)
+		}
+	case yaml.SequenceNode:
+		d.compareSequence(left, right, path, leftSource, rightSource)
+	}
+}
+
+// compareSequence matches items by id and falls back to position when
+// neither side's item has one.
+func (d *windowDiffer) compareSequence(left, right *yaml.Node, path, leftSource, rightSource string) {
+	matched := make([]bool, len(right.Content))
+	for i, item := range left.Content {
+		if id := itemID(item); id != "" {
+			index := indexByID(right, id)
+			var other *yaml.Node
+			if index != -1 && !matched[index] {
+				matched[index] = true
+				other = right.Content[index]
+			}
+			d.pair(item, other, fmt.Sprintf("%s[id=%s]", path, id), leftSource, rightSource)
+			continue
+		}
+		var other *yaml.Node
+		if i < len(right.Content) && !matched[i] && itemID(right.Content[i]) == "" {
+			matched[i] = true
+			other = right.Content[i]
+		}
+		d.pair(item, other, fmt.Sprintf("%s[%d]", path, i), leftSource, rightSource)
+	}
+	for i, item := range right.Content {
+		if matched[i] {
+			continue
+		}
+		itemPath := fmt.Sprintf("%s[%d]", path, i)
+		if id := itemID(item); id != "" {
+			itemPath = fmt.Sprintf("%s[id=%s]", path, id)
+		}
+		d.pair(nil, item, itemPath, leftSource, rightSource)
+	}
+}
+
+func (d *windowDiffer) pair(left, right *yaml.Node, path, leftSource, rightSource string) {
+	switch {
+	case left == nil && right == nil:
+	case left == nil:
+		d.add(DiffAdded, path, nil, right, "", d.source(d.right, right, rightSource))
+	case right == nil:
+		d.add(DiffRemoved, path, left, nil, d.source(d.left, left, leftSource), "")
+	default:
+		d.compare(left, right, path, leftSource, rightSource)
+	}
+}
+
+func (d *windowDiffer) source(side *diffSide, node *yaml.Node, inherited string) string {
+	if origin, ok := side.origins[node]; ok {
+		return origin
+	}
+	return inherited
+}
+
+func (d *windowDiffer) add(kind, path string, left, right *yaml.Node, leftSource, rightSource string) {
+	change := DiffChange{Kind: kind, YAMLPath: path, LeftSource: leftSource, RightSource: rightSource}
+	if left != nil {
+		change.Left = nodeValue(left)
+	}
+	if right != nil {
+		change.Right = nodeValue(right)
+	}
+	d.changes = append(d.changes, change)
+}
+
+func resolveAlias(node *yaml.Node) *yaml.Node {
+	for node.Kind == yaml.AliasNode && node.Alias != nil {
+		node = node.Alias
+	}
+	return node
+}
+
+func nodeValue(node *yaml.Node) interface{} {
+	var result interface{}
+	if err := node.Decode(&result); err != nil {
+		return node.Value
+	}
+	return result
+}
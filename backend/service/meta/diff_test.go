@@ -0,0 +1,74 @@
+package meta
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/viant/afs"
+)
+
+func TestDiffWindow_ReportsChangesWithSources(t *testing.T) {
+	root := t.TempDir()
+	mustWriteMetaFile(t, filepath.Join(root, "order", "main.yaml"), `namespace: order
+view:
+  content:
+    table:
+      columns: $import(columns.yaml)
+`)
+	mustWriteMetaFile(t, filepath.Join(root, "order", "columns.yaml"), `- id: name
+  width: 120
+- id: total
+  width: 80
+  target: web
+- id: legacy
+`)
+	mustWriteMetaFile(t, filepath.Join(root, "order", "ios", "tablet", "main.yaml"), `namespace: order
+view:
+  content:
+    table:
+      columns: $import(columns.yaml)
+`)
+	mustWriteMetaFile(t, filepath.Join(root, "order", "ios", "tablet", "columns.yaml"), `- id: name
+  width: 200
+- id: legacy
+- id: thumbnail
+`)
+	service := New(afs.New(), root)
+	diff, err := service.DiffWindow(context.Background(), "order", &TargetContext{Platform: "web", FormFactor: "desktop"}, &TargetContext{Platform: "ios", FormFactor: "tablet"})
+	if err != nil {
+		t.Fatalf("DiffWindow() error = %v", err)
+	}
+	if diff.Left != "web/desktop" || diff.Right != "ios/tablet" || !strings.HasSuffix(diff.RightURL, "order/ios/tablet/main.yaml") {
+		t.Fatalf("unexpected diff header %+v", diff)
+	}
+	changes := map[string]DiffChange{}
+	for _, change := range diff.Changes {
+		changes[change.YAMLPath] = change
+	}
+	if len(changes) != 3 {
+		t.Fatalf("expected 3 changes, got %+v", diff.Changes)
+	}
+	width := changes["$.view.content.table.columns[id=name].width"]
+	if width.Kind != DiffChanged || width.Left != 120 || width.Right != 200 {
+		t.Fatalf("unexpected width change %+v", width)
+	}
+	if !strings.HasSuffix(width.LeftSource, "order/columns.yaml") || !strings.HasSuffix(width.RightSource, "order/ios/tablet/columns.yaml") {
+		t.Fatalf("expected sources of both sides, got %+v", width)
+	}
+	if removed := changes["$.view.content.table.columns[id=total]"]; removed.Kind != DiffRemoved || removed.Right != nil {
+		t.Fatalf("unexpected removal %+v", removed)
+	}
+	if added := changes["$.view.content.table.columns[id=thumbnail]"]; added.Kind != DiffAdded || added.Left != nil || !strings.HasSuffix(added.RightSource, "ios/tablet/columns.yaml") {
+		t.Fatalf("unexpected addition %+v", added)
+	}
+
+	same, err := service.DiffWindow(context.Background(), "order", &TargetContext{Platform: "web"}, &TargetContext{Platform: "web", FormFactor: "desktop"})
+	if err != nil {
+		t.Fatalf("DiffWindow() error = %v", err)
+	}
+	if len(same.Changes) != 0 {
+		t.Fatalf("expected no changes, got %+v", same.Changes)
+	}
+}
@@ -0,0 +1,114 @@
+package meta
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+var yamlLinePattern = regexp.MustCompile(`line (\d+)(?::(\d+))?`)
+
+// LoadError describes a metadata load failure with the file and YAML
+// position that caused it, the $import chain leading there, and the branch
+// candidates that were tried.
+type LoadError struct {
+	URL         string   `json:"url,omitempty"`
+	Line        int      `json:"line,omitempty"`
+	Column      int      `json:"column,omitempty"`
+	Directive   string   `json:"directive,omitempty"`
+	ImportStack []string `json:"importStack,omitempty"`
+	Candidates  []string `json:"candidates,omitempty"`
+	Err         error    `json:"-"`
+}
+
+func (e *LoadError) Error() string {
+	message := "metadata load failed"
+	if e.Err != nil {
+		message = e.Err.Error()
+	}
+	location := e.URL
+	if e.Line > 0 {
+		location += ":" + strconv.Itoa(e.Line)
+		if e.Column > 0 {
+			location += ":" + strconv.Itoa(e.Column)
+		}
+	}
+	if location != "" && (e.Line > 0 || !strings.Contains(message, e.URL)) {
+		message = location + ": " + message
+	}
+	if len(e.ImportStack) > 1 {
+		message += " (import chain: " + strings.Join(e.ImportStack, " -> ") + ")"
+	}
+	return message
+}
+
+func (e *LoadError) Unwrap() error {
+	return e.Err
+}
+
+func (e *LoadError) MarshalJSON() ([]byte, error) {
+	type alias LoadError
+	message := ""
+	if e.Err != nil {
+		message = e.Err.Error()
+	}
+	return json.Marshal(struct {
+		*alias
+		Message string `json:"message"`
+	}{alias: (*alias)(e), Message: message})
+}
+
+// loadError wraps err with the session's current position unless err already
+// carries a more specific LoadError from a nested import.
+func (s *loadSession) loadError(URL string, node *yaml.Node, candidates []string, err error) error {
+	if err == nil {
+		return nil
+	}
+	var loadErr *LoadError
+	if errors.As(err, &loadErr) {
+		return err
+	}
+	result := &LoadError{URL: URL, Candidates: candidates, Err: err}
+	if len(s.stack) > 0 {
+		result.ImportStack = append([]string{}, s.stack...)
+	}
+	if node != nil {
+		result.Line, result.Column = node.Line, node.Column
+		if node.Kind == yaml.ScalarNode && isImportDirective(node.Value) {
+			result.Directive = strings.TrimSpace(node.Value)
+		}
+	} else {
+		result.Line, result.Column = yamlErrorPosition(err)
+	}
+	return result
+}
+
+// yamlErrorPosition extracts the first "line N[:M]" reference from a yaml.v3
+// parse or decode error.
+func yamlErrorPosition(err error) (int, int) {
+	match := yamlLinePattern.FindStringSubmatch(err.Error())
+	if match == nil {
+		return 0, 0
+	}
+	line, _ := strconv.Atoi(match[1])
+	column, _ := strconv.Atoi(match[2])
+	return line, column
+}
+
+func newBranchError(basePath, ext string, candidates []string) error {
+	tried := make([]string, 0, len(candidates))
+	for _, candidate := range candidates {
+		tried = append(tried, candidate+ext)
+	}
+	return &LoadError{
+		URL:        basePath + ext,
+		Candidates: tried,
+		Err:        fmt.Errorf("open %s%s: %w", basePath, ext, fs.ErrNotExist),
+	}
+}
@@ -0,0 +1,70 @@
+package meta
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/viant/afs"
+)
+
+func TestLoad_DanglingImportKeyReportsLoadError(t *testing.T) {
+	root := t.TempDir()
+	mustWriteMetaFile(t, filepath.Join(root, "window", "order", "main.yaml"), "namespace: order\nview:\n  content:\n    table: $import(table.yaml:missing.key)\n")
+	mustWriteMetaFile(t, filepath.Join(root, "window", "order", "table.yaml"), "id: orders\n")
+
+	service := New(afs.New(), filepath.Join(root, "window"))
+	var decoded map[string]any
+	err := service.Load(context.Background(), "order/main.yaml", &decoded)
+	var loadErr *LoadError
+	if !errors.As(err, &loadErr) {
+		t.Fatalf("expected LoadError, got %v", err)
+	}
+	if !strings.HasSuffix(loadErr.URL, "/order/main.yaml") || loadErr.Line != 4 || loadErr.Column != 12 {
+		t.Fatalf("unexpected position %s:%d:%d", loadErr.URL, loadErr.Line, loadErr.Column)
+	}
+	if loadErr.Directive != "$import(table.yaml:missing.key)" {
+		t.Fatalf("unexpected directive %q", loadErr.Directive)
+	}
+	if len(loadErr.ImportStack) != 1 || len(loadErr.Candidates) == 0 {
+		t.Fatalf("unexpected stack %v candidates %v", loadErr.ImportStack, loadErr.Candidates)
+	}
+	if !strings.Contains(err.Error(), "main.yaml:4:12:") {
+		t.Fatalf("unexpected message %q", err.Error())
+	}
+}
+
+func TestLoad_NestedParseErrorReportsImportChain(t *testing.T) {
+	root := t.TempDir()
+	mustWriteMetaFile(t, filepath.Join(root, "window", "a.yaml"), "next: $import(b.yaml)\n")
+	mustWriteMetaFile(t, filepath.Join(root, "window", "b.yaml"), "id: b\nbroken: [\n")
+
+	service := New(afs.New(), filepath.Join(root, "window"))
+	var decoded map[string]any
+	err := service.Load(context.Background(), "a.yaml", &decoded)
+	var loadErr *LoadError
+	if !errors.As(err, &loadErr) {
+		t.Fatalf("expected LoadError, got %v", err)
+	}
+	if !strings.HasSuffix(loadErr.URL, "/b.yaml") || loadErr.Line == 0 {
+		t.Fatalf("expected position in b.yaml, got %s:%d", loadErr.URL, loadErr.Line)
+	}
+	if len(loadErr.ImportStack) != 2 || !strings.HasSuffix(loadErr.ImportStack[0], "/a.yaml") {
+		t.Fatalf("unexpected import stack %v", loadErr.ImportStack)
+	}
+}
+
+func TestResolveWindowBase_MissingReportsCandidates(t *testing.T) {
+	root := t.TempDir()
+	service := New(afs.New(), filepath.Join(root, "window"))
+	_, err := service.ResolveWindowBase(context.Background(), "order/main", &TargetContext{Platform: "android", FormFactor: "phone"})
+	var loadErr *LoadError
+	if !errors.As(err, &loadErr) || len(loadErr.Candidates) < 2 {
+		t.Fatalf("expected LoadError with candidates, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "file does not exist") {
+		t.Fatalf("unexpected message %q", err.Error())
+	}
+}
@@ -0,0 +1,206 @@
+package meta
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	extendKey = "$extend"
+	patchKey  = "$patch"
+)
+
+// listOperations are the keys a patch may use instead of a sequence to edit
+// a base list by item id. They are applied in this order.
+var listOperations = []string{"$remove", "$replace", "$merge", "$append"}
+
+// resolveExtend expands a mapping holding $extend: the base node is imported
+// like $import, then the sibling keys and the optional $patch mapping are
+// merged over it:
+//
+//	content:
+//	  $extend: $import(../../shared/table.yaml:table)
+//	  title: Orders
+//	  columns:
+//	    $remove: [legacyId]
+//	    $replace: [{id: name, name: Customer}]
+//	    $merge: [{id: total, width: 80}]
+//	    $append: [{id: region}]
+//
+// Mappings merge deeply, sequences and scalars replace, and list operations
+// address base items by id.
+func (l *Service) resolveExtend(ctx context.Context, node *yaml.Node, baseDir string, session *loadSession) error {
+	current := session.current()
+	extendNode := mappingValue(node, extendKey)
+	directive, ok := extendDirective(extendNode)
+	if !ok {
+		return session.loadError(current, extendNode, nil, fmt.Errorf("%s expects an $import directive or path", extendKey))
+	}
+	base := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: directive, Line: extendNode.Line, Column: extendNode.Column}
+	if err := l.processNode(ctx, base, baseDir, session); err != nil {
+		return err
+	}
+
+	patch := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	var explicit *yaml.Node
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		switch node.Content[i].Value {
+		case extendKey:
+		case patchKey:
+			explicit = node.Content[i+1]
+		default:
+			patch.Content = append(patch.Content, node.Content[i], node.Content[i+1])
+		}
+	}
+	if explicit != nil {
+		if explicit.Kind != yaml.MappingNode {
+			return session.loadError(current, explicit, nil, fmt.Errorf("%s expects a mapping", patchKey))
+		}
+		patch.Content = append(patch.Content, explicit.Content...)
+	}
+	if err := l.resolveImports(ctx, patch, baseDir, session); err != nil {
+		return err
+	}
+	if len(patch.Content) > 0 && base.Kind != yaml.MappingNode {
+		return session.loadError(current, extendNode, nil, fmt.Errorf("%s base %s is not a mapping", extendKey, directive))
+	}
+
+	origin := session.origins[base]
+	// Patch values came from the current file, not from the base import.
+	markOrigin := func(value *yaml.Node) {
+		if current != "" && current != origin {
+			session.setOrigin(value, current)
+		}
+	}
+	if err := patchMapping(base, patch, markOrigin); err != nil {
+		return session.loadError(current, node, nil, err)
+	}
+	*node = *base
+	if origin != "" {
+		session.setOrigin(node, origin)
+	}
+	return nil
+}
+
+// extendDirective normalizes the $extend value to an $import directive.
+func extendDirective(node *yaml.Node) (string, bool) {
+	if node == nil || node.Kind != yaml.ScalarNode {
+		return "", false
+	}
+	value := strings.TrimSpace(node.Value)
+	if value == "" {
+		return "", false
+	}
+	if isImportDirective(value) {
+		return value, true
+	}
+	return "$import(" + value + ")", true
+}
+
+// patchMapping merges patch into base; see resolveExtend for the rules.
+func patchMapping(base, patch *yaml.Node, markOrigin func(*yaml.Node)) error {
+	for i := 0; i+1 < len(patch.Content); i += 2 {
+		key, value := patch.Content[i].Value, patch.Content[i+1]
+		current := mappingValue(base, key)
+		switch {
+		case isListPatch(value):
+			if current == nil {
+				current = &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+				setMappingValue(base, key, current)
+			}
+			if current.Kind != yaml.SequenceNode {
+				return fmt.Errorf("%s: list operations require a sequence", key)
+			}
+			if err := patchSequence(current, value, markOrigin); err != nil {
+				return fmt.Errorf("%s: %w", key, err)
+			}
+		case current != nil && current.Kind == yaml.MappingNode && value.Kind == yaml.MappingNode:
+			if err := patchMapping(current, value, markOrigin); err != nil {
+				return fmt.Errorf("%s: %w", key, err)
+			}
+		case current == nil:
+			// Keep the patch key node so positions point at the patching file.
+			markOrigin(value)
+			base.Content = append(base.Content, patch.Content[i], value)
+		default:
+			markOrigin(value)
+			setMappingValue(base, key, value)
+		}
+	}
+	return nil
+}
+
+// isListPatch reports whether value is a mapping of list operations only.
+func isListPatch(value *yaml.Node) bool {
+	if value == nil || value.Kind != yaml.MappingNode || len(value.Content) == 0 {
+		return false
+	}
+	for i := 0; i+1 < len(value.Content); i += 2 {
+		if !containsString(listOperations, value.Content[i].Value) {
+			return false
+		}
+	}
+	return true
+}
+
+// patchSequence applies $remove, $replace, $merge and $append to base.
+func patchSequence(base, operations *yaml.Node, markOrigin func(*yaml.Node)) error {
+	for _, operation := range listOperations {
+		items := mappingValue(operations, operation)
+		if items == nil {
+			continue
+		}
+		if items.Kind != yaml.SequenceNode {
+			return fmt.Errorf("%s expects a sequence", operation)
+		}
+		for _, item := range items.Content {
+			if operation == "$append" {
+				markOrigin(item)
+				base.Content = append(base.Content, item)
+				continue
+			}
+			id := item.Value
+			if item.Kind == yaml.MappingNode {
+				id = itemID(item)
+			}
+			index := indexByID(base, id)
+			if id == "" || index == -1 {
+				return fmt.Errorf("%s: item with id %q not found", operation, id)
+			}
+			switch operation {
+			case "$remove":
+				base.Content = append(base.Content[:index], base.Content[index+1:]...)
+			case "$replace":
+				markOrigin(item)
+				base.Content[index] = item
+			case "$merge":
+				if base.Content[index].Kind != yaml.MappingNode {
+					return fmt.Errorf("%s: item with id %q is not a mapping", operation, id)
+				}
+				if err := patchMapping(base.Content[index], item, markOrigin); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func itemID(node *yaml.Node) string {
+	if value := mappingValue(node, "id"); value != nil && value.Kind == yaml.ScalarNode {
+		return value.Value
+	}
+	return ""
+}
+
+func indexByID(sequence *yaml.Node, id string) int {
+	for i, item := range sequence.Content {
+		if itemID(item) == id {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,123 @@
+package meta
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/viant/afs"
+)
+
+const sharedTableYAML = `table:
+  id: orders
+  title: Orders
+  dataSourceRef: orders
+  style:
+    width: 100%
+    height: 300px
+  columns:
+    - id: name
+      name: Name
+    - id: total
+      name: Total
+      width: 120
+    - id: legacy
+      name: Legacy
+`
+
+func TestLoad_ExtendAppliesPatchAndListOperations(t *testing.T) {
+	root := t.TempDir()
+	base := filepath.Join(root, "window", "order")
+	mustWriteMetaFile(t, filepath.Join(base, "shared", "table.yaml"), sharedTableYAML)
+	mustWriteMetaFile(t, filepath.Join(base, "android", "phone", "main.yaml"), `view:
+  content:
+    $extend: $import(../../shared/table.yaml:table)
+    title: Mobile orders
+    style:
+      height: 200px
+    columns:
+      $remove: [legacy]
+      $replace:
+        - id: name
+          name: Customer
+      $merge:
+        - id: total
+          width: 80
+      $append:
+        - id: region
+          name: Region
+    $patch:
+      dataSourceRef: mobileOrders
+`)
+
+	service := New(afs.New(), filepath.Join(root, "window"))
+	var decoded struct {
+		View struct {
+			Content struct {
+				ID            string            `yaml:"id"`
+				Title         string            `yaml:"title"`
+				DataSourceRef string            `yaml:"dataSourceRef"`
+				Style         map[string]string `yaml:"style"`
+				Columns       []map[string]any  `yaml:"columns"`
+			} `yaml:"content"`
+		} `yaml:"view"`
+	}
+	if err := service.LoadWithTarget(context.Background(), "order/android/phone/main.yaml", &decoded, &TargetContext{Platform: "android", FormFactor: "phone"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	content := decoded.View.Content
+	if content.ID != "orders" || content.Title != "Mobile orders" || content.DataSourceRef != "mobileOrders" {
+		t.Fatalf("unexpected content %+v", content)
+	}
+	if content.Style["width"] != "100%" || content.Style["height"] != "200px" {
+		t.Fatalf("expected deep merged style, got %v", content.Style)
+	}
+	var summary []string
+	for _, column := range content.Columns {
+		summary = append(summary, strings.TrimSpace(column["id"].(string)+":"+column["name"].(string)))
+	}
+	if got := strings.Join(summary, ","); got != "name:Customer,total:Total,region:Region" {
+		t.Fatalf("unexpected columns %q", got)
+	}
+	if width := content.Columns[1]["width"]; width != 80 {
+		t.Fatalf("expected merged width 80, got %v", width)
+	}
+}
+
+func TestLoad_ExtendUnknownListItemReportsError(t *testing.T) {
+	root := t.TempDir()
+	mustWriteMetaFile(t, filepath.Join(root, "window", "order", "table.yaml"), sharedTableYAML)
+	mustWriteMetaFile(t, filepath.Join(root, "window", "order", "main.yaml"), "content:\n  $extend: table.yaml:table\n  columns:\n    $remove: [missing]\n")
+
+	service := New(afs.New(), filepath.Join(root, "window"))
+	var decoded map[string]any
+	err := service.Load(context.Background(), "order/main.yaml", &decoded)
+	var loadErr *LoadError
+	if !errors.As(err, &loadErr) || loadErr.Line != 2 || !strings.Contains(err.Error(), `columns: $remove: item with id "missing" not found`) {
+		t.Fatalf("expected LoadError for missing list item, got %v", err)
+	}
+}
+
+func TestValidateWindow_ExtendAttributesPatchToExtendingFile(t *testing.T) {
+	root := t.TempDir()
+	base := filepath.Join(root, "window", "order")
+	mustWriteMetaFile(t, filepath.Join(base, "table.yaml"), "table:\n  id: orders\n  titel: Orders\n")
+	mustWriteMetaFile(t, filepath.Join(base, "main.yaml"), "view:\n  content:\n    $extend: table.yaml:table\n    subtitel: Mobile\n")
+
+	service := New(afs.New(), filepath.Join(root, "window"))
+	diagnostics, err := service.ValidateWindow(context.Background(), "order/main.yaml", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(diagnostics) != 2 {
+		t.Fatalf("expected two diagnostics, got %v", diagnostics)
+	}
+	if !strings.HasSuffix(diagnostics[0].SourcePath, "/order/table.yaml") || diagnostics[0].Line != 3 {
+		t.Fatalf("expected base diagnostic in table.yaml, got %v", diagnostics[0])
+	}
+	if !strings.HasSuffix(diagnostics[1].SourcePath, "/order/main.yaml") || diagnostics[1].Line != 4 {
+		t.Fatalf("expected patch diagnostic in main.yaml, got %#v", diagnostics[1])
+	}
+}
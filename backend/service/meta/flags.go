@@ -0,0 +1,291 @@
+package meta
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"sync"
+
+	"github.com/viant/afs"
+	"github.com/viant/forge/backend/types"
+	"gopkg.in/yaml.v3"
+)
+
+// FlagProvider reports the feature flags enabled for a target. target
+// carries the caller identity, so providers may roll flags out per user.
+type FlagProvider interface {
+	Flags(ctx context.Context, target *TargetContext) ([]string, error)
+}
+
+// EnableFlags resolves TargetSpec flags through provider: LoadForTarget sets
+// TargetContext.Flags from the provider before target matching.
+func (l *Service) EnableFlags(provider FlagProvider) *Service {
+	l.flags = provider
+	return l
+}
+
+// withFlags returns a copy of target with the provider's flags.
+func (l *Service) withFlags(ctx context.Context, target *TargetContext) (*TargetContext, error) {
+	if l.flags == nil {
+		return target, nil
+	}
+	flags, err := l.flags.Flags(ctx, target)
+	if err != nil {
+		return nil, fmt.Errorf("resolve feature flags: %w", err)
+	}
+	result := &TargetContext{}
+	if target != nil {
+		*result = *target
+	}
+	result.Flags = append(append([]string{}, result.Flags...), flags...)
+	return result, nil
+}
+
+// FlagRule is one flag in a FileFlagProvider document. A bare boolean sets
+// Enabled; otherwise the flag is on when Enabled (default true) and every
+// given condition holds:
+//
+//	newOrders: true
+//	betaDashboard:
+//	  target: web
+//	  roles: [tester]
+//	  percentage: 20
+type FlagRule struct {
+	Enabled    *bool             `yaml:"enabled,omitempty"`
+	Target     *types.TargetSpec `yaml:"target,omitempty"`
+	Roles      []string          `yaml:"roles,omitempty"`
+	Users      []string          `yaml:"users,omitempty"`
+	Percentage *int              `yaml:"percentage,omitempty"`
+}
+
+func (r *FlagRule) UnmarshalYAML(node *yaml.Node) error {
+	var enabled bool
+	if node.Kind == yaml.ScalarNode && node.Decode(&enabled) == nil {
+		r.Enabled = &enabled
+		return nil
+	}
+	type alias FlagRule
+	var expanded alias
+	if err := node.Decode(&expanded); err != nil {
+		return fmt.Errorf("invalid flag rule: %w", err)
+	}
+	*r = FlagRule(expanded)
+	return nil
+}
+
+// matches evaluates the rule for target. Percentage rollouts hash the flag
+// name with the caller subject, so a user stays in or out consistently.
+func (r *FlagRule) matches(name string, target *TargetContext) bool {
+	if r.Enabled != nil && !*r.Enabled {
+		return false
+	}
+	if r.Target != nil && !MatchesTarget(r.Target, target) {
+		return false
+	}
+	caller := target.caller()
+	if len(r.Roles) > 0 {
+		allowed := false
+		for _, role := range r.Roles {
+			allowed = allowed || caller.HasRole(role)
+		}
+		if !allowed {
+			return false
+		}
+	}
+	if len(r.Users) > 0 && (caller == nil || !(containsString(r.Users, caller.Subject) || containsString(r.Users, caller.Email))) {
+		return false
+	}
+	if r.Percentage != nil {
+		if caller == nil || caller.Subject == "" {
+			return *r.Percentage >= 100
+		}
+		hash := fnv.New32a()
+		_, _ = hash.Write([]byte(name + "|" + caller.Subject))
+		return int(hash.Sum32()%100) < *r.Percentage
+	}
+	return true
+}
+
+// FileFlagProvider reads flag rules from a YAML file mapping flag names to
+// FlagRule values. The file is re-read when its modification time or size
+// changes.
+type FileFlagProvider struct {
+	fs  afs.Service
+	URL string
+
+	mu          sync.Mutex
+	fingerprint string
+	rules       map[string]*FlagRule
+}
+
+// NewFileFlagProvider creates a provider over the flag file at URL.
+func NewFileFlagProvider(fs afs.Service, URL string) *FileFlagProvider {
+	return &FileFlagProvider{fs: fs, URL: URL}
+}
+
+// Flags returns the enabled flag names; a missing file enables none.
+func (p *FileFlagProvider) Flags(ctx context.Context, target *TargetContext) ([]string, error) {
+	rules, err := p.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var result []string
+	for name, rule := range rules {
+		if rule.matches(name, target) {
+			result = append(result, name)
+		}
+	}
+	return result, nil
+}
+
+func (p *FileFlagProvider) load(ctx context.Context) (map[string]*FlagRule, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	object, err := p.fs.Object(ctx, p.URL)
+	if err != nil {
+		if ok, existsErr := p.fs.Exists(ctx, p.URL); existsErr == nil && !ok {
+			p.rules, p.fingerprint = nil, ""
+			return nil, nil
+		}
+		return nil, fmt.Errorf("open flag file %s: %w", p.URL, err)
+	}
+	fingerprint := fmt.Sprint(object.ModTime().UnixNano(), "-", object.Size())
+	if p.rules != nil && fingerprint == p.fingerprint {
+		return p.rules, nil
+	}
+	data, err := p.fs.DownloadWithURL(ctx, p.URL)
+	if err != nil {
+		return nil, fmt.Errorf("read flag file %s: %w", p.URL, err)
+	}
+	rules := map[string]*FlagRule{}
+	if err := yaml.Unmarshal(data, &rules); err != nil {
+		return nil, &LoadError{URL: p.URL, Err: err}
+	}
+	p.rules, p.fingerprint = rules, fingerprint
+	return rules, nil
+}
+
+// matchesFlags reports whether every expression holds for the enabled
+// flags; a malformed expression never matches.
+func matchesFlags(expressions []string, enabled []string) bool {
+	for _, expression := range expressions {
+		ok, err := evalFlagExpression(expression, enabled)
+		if err != nil || !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// evalFlagExpression evaluates names combined with !, &&, || and
+// parentheses; && binds tighter than ||.
+func evalFlagExpression(expression string, enabled []string) (bool, error) {
+	parser := &flagParser{tokens: tokenizeFlags(expression), enabled: enabled}
+	if len(parser.tokens) == 0 {
+		return false, fmt.Errorf("empty flag expression")
+	}
+	result, err := parser.or()
+	if err != nil {
+		return false, err
+	}
+	if parser.position < len(parser.tokens) {
+		return false, fmt.Errorf("unexpected %q in flag expression %q", parser.tokens[parser.position], expression)
+	}
+	return result, nil
+}
+
+// ValidateFlagExpression reports whether expression is well formed.
+func ValidateFlagExpression(expression string) error {
+	_, err := evalFlagExpression(expression, nil)
+	return err
+}
+
+func tokenizeFlags(expression string) []string {
+	var result []string
+	for i := 0; i < len(expression); {
+		switch c := expression[i]; {
+		case c == ' ' || c == '\t':
+			i++
+		case c == '(' || c == ')' || c == '!':
+			result = append(result, string(c))
+			i++
+		case strings.HasPrefix(expression[i:], "&&") || strings.HasPrefix(expression[i:], "||"):
+			result = append(result, expression[i:i+2])
+			i += 2
+		default:
+			start := i
+			for i < len(expression) && !strings.ContainsRune(" \t()!&|", rune(expression[i])) {
+				i++
+			}
+			if start == i {
+				// A lone & or | is kept so the parser reports it.
+				i++
+			}
+			result = append(result, expression[start:i])
+		}
+	}
+	return result
+}
+
+type flagParser struct {
+	tokens   []string
+	position int
+	enabled  []string
+}
+
+func (p *flagParser) peek() string {
+	if p.position < len(p.tokens) {
+		return p.tokens[p.position]
+	}
+	return ""
+}
+
+func (p *flagParser) or() (bool, error) {
+	result, err := p.and()
+	for err == nil && p.peek() == "||" {
+		p.position++
+		var next bool
+		if next, err = p.and(); err == nil {
+			result = result || next
+		}
+	}
+	return result, err
+}
+
+func (p *flagParser) and() (bool, error) {
+	result, err := p.unary()
+	for err == nil && p.peek() == "&&" {
+		p.position++
+		var next bool
+		if next, err = p.unary(); err == nil {
+			result = result && next
+		}
+	}
+	return result, err
+}
+
+func (p *flagParser) unary() (bool, error) {
+	switch token := p.peek(); token {
+	case "!":
+		p.position++
+		result, err := p.unary()
+		return !result, err
+	case "(":
+		p.position++
+		result, err := p.or()
+		if err != nil {
+			return false, err
+		}
+		if p.peek() != ")" {
+			return false, fmt.Errorf("missing ) in flag expression")
+		}
+		p.position++
+		return result, nil
+	case "", ")", "&&", "||", "&", "|":
+		return false, fmt.Errorf("expected flag name, got %q", token)
+	default:
+		p.position++
+		return containsString(p.enabled, token), nil
+	}
+}
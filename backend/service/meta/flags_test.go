@@ -0,0 +1,171 @@
+package meta
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/viant/afs"
+	"github.com/viant/forge/backend/service/identity"
+	"github.com/viant/forge/backend/types"
+)
+
+func TestEvalFlagExpression(t *testing.T) {
+	enabled := []string{"newOrders", "beta"}
+	testCases := []struct {
+		expression string
+		expected   bool
+		invalid    bool
+	}{
+		{expression: "newOrders", expected: true},
+		{expression: "legacyGrid", expected: false},
+		{expression: "!legacyGrid", expected: true},
+		{expression: "beta && !legacyGrid", expected: true},
+		{expression: "legacyGrid || beta && newOrders", expected: true},
+		{expression: "(legacyGrid || beta) && !newOrders", expected: false},
+		{expression: "!!beta", expected: true},
+		{expression: "beta &&", invalid: true},
+		{expression: "(beta", invalid: true},
+		{expression: "beta & newOrders", invalid: true},
+		{expression: "beta newOrders", invalid: true},
+		{expression: " ", invalid: true},
+	}
+	for _, testCase := range testCases {
+		actual, err := evalFlagExpression(testCase.expression, enabled)
+		if testCase.invalid {
+			if err == nil {
+				t.Fatalf("%q: expected error, got %v", testCase.expression, actual)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%q: unexpected error %v", testCase.expression, err)
+		}
+		if actual != testCase.expected {
+			t.Fatalf("%q: expected %v, got %v", testCase.expression, testCase.expected, actual)
+		}
+	}
+}
+
+func TestMatchesTarget_Flags(t *testing.T) {
+	target := &TargetContext{Platform: "web", Flags: []string{"newOrders"}}
+	if !MatchesTarget(&types.TargetSpec{Flags: []string{"newOrders"}}, target) {
+		t.Fatalf("expected enabled flag to match")
+	}
+	if MatchesTarget(&types.TargetSpec{Flags: []string{"newOrders", "beta"}}, target) {
+		t.Fatalf("expected every flag entry to be required")
+	}
+	if MatchesTarget(&types.TargetSpec{Flags: []string{"newOrders &&"}}, target) {
+		t.Fatalf("expected malformed expression not to match")
+	}
+	if !MatchesTarget(&types.TargetSpec{Flags: []string{"!beta"}}, nil) {
+		t.Fatalf("expected negated flag to match nil target")
+	}
+}
+
+func TestFileFlagProvider_Flags(t *testing.T) {
+	root := t.TempDir()
+	flagsPath := filepath.Join(root, "flags.yaml")
+	mustWriteMetaFile(t, flagsPath, `newOrders: true
+legacyGrid: false
+mobileOnly:
+  target: [android, ios]
+testers:
+  roles: [tester]
+named:
+  users: [ann@example.com]
+everyone:
+  percentage: 100
+nobody:
+  percentage: 0
+`)
+	provider := NewFileFlagProvider(afs.New(), flagsPath)
+	flags := func(target *TargetContext) []string {
+		result, err := provider.Flags(context.Background(), target)
+		if err != nil {
+			t.Fatalf("Flags() error = %v", err)
+		}
+		sort.Strings(result)
+		return result
+	}
+
+	if actual, expected := flags(&TargetContext{Platform: "web"}), []string{"everyone", "newOrders"}; !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("expected %v, got %v", expected, actual)
+	}
+	tester := &identity.Identity{Subject: "u1", Email: "ann@example.com", Roles: []string{"tester"}}
+	if actual, expected := flags(&TargetContext{Platform: "ios", Identity: tester}), []string{"everyone", "mobileOnly", "named", "newOrders", "testers"}; !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("expected %v, got %v", expected, actual)
+	}
+
+	mustWriteMetaFile(t, flagsPath, "legacyGrid: true\n")
+	if actual, expected := flags(nil), []string{"legacyGrid"}; !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("expected reloaded flags %v, got %v", expected, actual)
+	}
+
+	missing := NewFileFlagProvider(afs.New(), filepath.Join(root, "missing.yaml"))
+	if result, err := missing.Flags(context.Background(), nil); err != nil || len(result) != 0 {
+		t.Fatalf("expected no flags for missing file, got %v, %v", result, err)
+	}
+}
+
+func TestFlagRule_PercentageIsStablePerUser(t *testing.T) {
+	percentage := 50
+	rule := &FlagRule{Percentage: &percentage}
+	in := 0
+	for _, subject := range []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j", "k", "l"} {
+		target := &TargetContext{Identity: &identity.Identity{Subject: subject}}
+		first := rule.matches("rollout", target)
+		if first != rule.matches("rollout", target) {
+			t.Fatalf("expected stable rollout for %s", subject)
+		}
+		if first {
+			in++
+		}
+	}
+	if in == 0 || in == 12 {
+		t.Fatalf("expected a partial rollout, got %d of 12", in)
+	}
+	if rule.matches("rollout", nil) {
+		t.Fatalf("expected anonymous caller outside a partial rollout")
+	}
+}
+
+func TestLoadForTarget_ResolvesFlagsThroughProvider(t *testing.T) {
+	root := t.TempDir()
+	mustWriteMetaFile(t, filepath.Join(root, "flags.yaml"), "newOrders: true\n")
+	mustWriteMetaFile(t, filepath.Join(root, "order.yaml"), `namespace: order
+view:
+  content:
+    table:
+      columns:
+        - id: name
+        - id: legacyStatus
+          target: {flags: ["!newOrders"]}
+        - id: fulfilment
+          target: {flags: [newOrders]}
+        - id: forecast
+          target: {flags: ["newOrders && forecast"]}
+`)
+	load := func(service *Service) []string {
+		window := &types.Window{}
+		if err := service.LoadForTarget(context.Background(), "order.yaml", window, &TargetContext{Platform: "web"}); err != nil {
+			t.Fatalf("LoadForTarget() error = %v", err)
+		}
+		var columns []string
+		for _, column := range window.View.Content.Table.Columns {
+			columns = append(columns, column.ID)
+		}
+		return columns
+	}
+
+	fs := afs.New()
+	if actual, expected := load(New(fs, root)), []string{"name", "legacyStatus"}; !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("without provider: expected %v, got %v", expected, actual)
+	}
+	service := New(fs, root).EnableFlags(NewFileFlagProvider(fs, filepath.Join(root, "flags.yaml")))
+	if actual, expected := load(service), []string{"name", "fulfilment"}; !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("with provider: expected %v, got %v", expected, actual)
+	}
+}
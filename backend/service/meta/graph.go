@@ -0,0 +1,96 @@
+package meta
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// maxImportDepth bounds $import nesting so non-canonical cycles (for example
+// paths that differ only by "..") still fail instead of exhausting the stack.
+const maxImportDepth = 32
+
+// ImportGraph lists the files a document resolved to for one target.
+type ImportGraph struct {
+	Root  string       `json:"root"`
+	Files []string     `json:"files"`
+	Edges []ImportEdge `json:"edges"`
+}
+
+// ImportEdge records one $import directive and the branch file that
+// satisfied it.
+type ImportEdge struct {
+	From       string            `json:"from"`
+	Directive  string            `json:"directive"`
+	Path       string            `json:"path"`
+	Key        string            `json:"key,omitempty"`
+	Arguments  map[string]string `json:"arguments,omitempty"`
+	Resolved   string            `json:"resolved"`
+	Candidates []string          `json:"candidates,omitempty"`
+	Line       int               `json:"line,omitempty"`
+	Column     int               `json:"column,omitempty"`
+}
+
+// ImportCycleError reports an $import chain that revisits a file or exceeds
+// maxImportDepth.
+type ImportCycleError struct {
+	Chain    []string
+	MaxDepth int
+}
+
+func (e *ImportCycleError) Error() string {
+	chain := strings.Join(e.Chain, " -> ")
+	if e.MaxDepth > 0 {
+		return fmt.Sprintf("import depth exceeds %d: %s", e.MaxDepth, chain)
+	}
+	return "import cycle: " + chain
+}
+
+// ImportGraph resolves path for target and returns every file and $import
+// edge involved, bypassing the cache so the graph always reflects storage.
+func (l *Service) ImportGraph(ctx context.Context, path string, target *TargetContext) (*ImportGraph, error) {
+	session := newLoadSession(target)
+	URL := l.getURL(path)
+	if _, err := l.resolveNode(ctx, URL, session); err != nil {
+		return nil, err
+	}
+	result := &ImportGraph{Root: URL, Files: session.sources, Edges: session.edges}
+	if len(session.sources) > 0 {
+		result.Root = session.sources[0]
+	}
+	return result, nil
+}
+
+// enter pushes URL onto the import stack, rejecting cycles and runaway depth.
+func (s *loadSession) enter(URL string) error {
+	for _, item := range s.stack {
+		if item == URL {
+			chain := append(append([]string{}, s.stack...), URL)
+			return &ImportCycleError{Chain: chain}
+		}
+	}
+	if len(s.stack) >= maxImportDepth {
+		chain := append(append([]string{}, s.stack...), URL)
+		return &ImportCycleError{Chain: chain, MaxDepth: maxImportDepth}
+	}
+	s.stack = append(s.stack, URL)
+	return nil
+}
+
+func (s *loadSession) leave() {
+	if len(s.stack) > 0 {
+		s.stack = s.stack[:len(s.stack)-1]
+	}
+}
+
+// current returns the file whose content is being resolved.
+func (s *loadSession) current() string {
+	if len(s.stack) == 0 {
+		return ""
+	}
+	return s.stack[len(s.stack)-1]
+}
+
+func (s *loadSession) addEdge(edge ImportEdge) {
+	s.edges = append(s.edges, edge)
+}
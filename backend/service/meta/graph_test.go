@@ -0,0 +1,72 @@
+package meta
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/viant/afs"
+)
+
+func TestLoad_ImportCycleReportsChain(t *testing.T) {
+	root := t.TempDir()
+	mustWriteMetaFile(t, filepath.Join(root, "window", "a.yaml"), "id: a\nnext: $import(b.yaml)\n")
+	mustWriteMetaFile(t, filepath.Join(root, "window", "b.yaml"), "id: b\nnext: $import(a.yaml)\n")
+
+	service := New(afs.New(), filepath.Join(root, "window"))
+	var decoded map[string]any
+	err := service.Load(context.Background(), "a.yaml", &decoded)
+	var cycle *ImportCycleError
+	if !errors.As(err, &cycle) {
+		t.Fatalf("expected ImportCycleError, got %v", err)
+	}
+	if len(cycle.Chain) != 3 || !strings.HasSuffix(cycle.Chain[0], "/a.yaml") || !strings.HasSuffix(cycle.Chain[1], "/b.yaml") || cycle.Chain[0] != cycle.Chain[2] {
+		t.Fatalf("unexpected chain %v", cycle.Chain)
+	}
+	if !strings.Contains(err.Error(), "import cycle:") {
+		t.Fatalf("unexpected message %q", err.Error())
+	}
+}
+
+func TestLoad_ImportDepthIsBounded(t *testing.T) {
+	root := t.TempDir()
+	mustWriteMetaFile(t, filepath.Join(root, "window", "deep", "a.yaml"), "next: $import(../deep/a.yaml)\n")
+
+	service := New(afs.New(), filepath.Join(root, "window"))
+	var decoded map[string]any
+	err := service.Load(context.Background(), "deep/a.yaml", &decoded)
+	var cycle *ImportCycleError
+	if !errors.As(err, &cycle) {
+		t.Fatalf("expected ImportCycleError, got %v", err)
+	}
+}
+
+func TestImportGraph_ReportsResolvedBranchFiles(t *testing.T) {
+	root := t.TempDir()
+	base := filepath.Join(root, "window", "demo")
+	mustWriteMetaFile(t, filepath.Join(base, "shared", "content.yaml"), "id: shared-default\n")
+	mustWriteMetaFile(t, filepath.Join(base, "mobile", "phone", "content.yaml"), "id: mobile-phone\n")
+	mustWriteMetaFile(t, filepath.Join(base, "shared", "main.yaml"), "namespace: demo\nview:\n  content:\n    table: $import('content.yaml')\n")
+	mustWriteMetaFile(t, filepath.Join(base, "mobile", "phone", "main.yaml"), "$import('../../shared/main.yaml')\n")
+
+	service := New(afs.New(), filepath.Join(root, "window"))
+	graph, err := service.ImportGraph(context.Background(), "demo/mobile/phone/main.yaml", &TargetContext{Platform: "android", FormFactor: "phone"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(graph.Files) != 3 || len(graph.Edges) != 2 {
+		t.Fatalf("unexpected graph: %#v", graph)
+	}
+	content := graph.Edges[1]
+	if !strings.HasSuffix(content.From, "/demo/shared/main.yaml") {
+		t.Fatalf("expected content import from shared main, got %q", content.From)
+	}
+	if !strings.HasSuffix(content.Resolved, "/demo/mobile/phone/content.yaml") {
+		t.Fatalf("expected mobile phone branch to satisfy import, got %q", content.Resolved)
+	}
+	if len(content.Candidates) < 2 || content.Line != 4 {
+		t.Fatalf("expected candidates and source line, got %#v", content)
+	}
+}
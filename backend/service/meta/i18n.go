@@ -0,0 +1,300 @@
+package meta
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	i18nKey               = "i18n"
+	i18nDefaultKey        = "default"
+	defaultCatalogPath    = "i18n"
+	defaultCatalogLocale  = "en"
+	translateDirectiveTag = "$t("
+)
+
+var translateDirective = regexp.MustCompile(`\$t\(\s*([^()\s]+)\s*\)`)
+
+// localeTag is the shape of language tags catalogs are looked up by; it keeps
+// separators and dots out of catalog paths.
+var localeTag = regexp.MustCompile(`^[A-Za-z]{2,8}(-[A-Za-z0-9]{1,8})*$`)
+
+// I18nOption configures message catalog resolution.
+type I18nOption func(*i18n)
+
+// WithCatalogPath sets the folder, relative to the metadata root, holding
+// one <locale>.yaml catalog per language tag. Defaults to "i18n".
+func WithCatalogPath(path string) I18nOption {
+	return func(c *i18n) {
+		if path = strings.TrimSpace(path); path != "" {
+			c.catalogPath = path
+		}
+	}
+}
+
+// WithDefaultLocale sets the locale consulted after every requested one.
+// Defaults to "en".
+func WithDefaultLocale(tag string) I18nOption {
+	return func(c *i18n) {
+		if tag = canonicalLocale(tag); tag != "" {
+			c.defaultLocale = tag
+		}
+	}
+}
+
+type i18n struct {
+	catalogPath   string
+	defaultLocale string
+}
+
+func newI18n(options ...I18nOption) *i18n {
+	result := &i18n{catalogPath: defaultCatalogPath, defaultLocale: defaultCatalogLocale}
+	for _, option := range options {
+		if option != nil {
+			option(result)
+		}
+	}
+	return result
+}
+
+// ConfigureI18n enables localization and sets where message catalogs are
+// read from. Once configured, LoadForTarget replaces $t(key) in strings and
+// mappings of the form {i18n: key, default: text} with the message for
+// TargetContext.Locale; until then such values are left as written.
+// Catalogs are YAML files whose nested keys join with dots:
+//
+//	# i18n/pt-BR.yaml
+//	orders:
+//	  title: Pedidos
+//
+// Each key is looked up through LocaleChain, so a regional catalog only needs
+// the messages that differ from its language. Missing keys fall back to the
+// default text, then to the key itself.
+func (l *Service) ConfigureI18n(options ...I18nOption) *Service {
+	l.i18n = newI18n(options...)
+	return l
+}
+
+// Localize resolves message references in node for target's locale. It is
+// a no-op until ConfigureI18n is called.
+func (l *Service) Localize(ctx context.Context, node *yaml.Node, target *TargetContext) error {
+	config := l.i18n
+	if config == nil || node == nil || !hasMessageReference(node) {
+		return nil
+	}
+	locale := ""
+	if target != nil {
+		locale = target.Locale
+	}
+	var catalogs []map[string]string
+	for _, tag := range LocaleChain(locale, config.defaultLocale) {
+		catalog, err := l.loadCatalog(ctx, config.catalogPath, tag)
+		if err != nil {
+			return err
+		}
+		if catalog != nil {
+			catalogs = append(catalogs, catalog)
+		}
+	}
+	translateNode(node, func(key string) (string, bool) {
+		for _, catalog := range catalogs {
+			if message, ok := catalog[key]; ok {
+				return message, true
+			}
+		}
+		return "", false
+	})
+	return nil
+}
+
+// loadCatalog returns the flattened catalog for tag, or nil when the
+// metadata tree has none.
+func (l *Service) loadCatalog(ctx context.Context, catalogPath, tag string) (map[string]string, error) {
+	URL := l.getURL(joinMetaPath(catalogPath, tag+".yaml"))
+	if _, ok, err := l.locate(ctx, URL); err != nil || !ok {
+		return nil, nil
+	}
+	node, err := l.loadNode(ctx, URL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("load message catalog %s: %w", tag, err)
+	}
+	result := map[string]string{}
+	flattenCatalog(getContentNode(node), "", result)
+	return result, nil
+}
+
+func flattenCatalog(node *yaml.Node, prefix string, result map[string]string) {
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i].Value
+			if prefix != "" {
+				key = prefix + "." + key
+			}
+			flattenCatalog(node.Content[i+1], key, result)
+		}
+	case yaml.ScalarNode:
+		if prefix != "" {
+			result[prefix] = node.Value
+		}
+	}
+}
+
+func hasMessageReference(node *yaml.Node) bool {
+	if _, ok := messageMapping(node); ok {
+		return true
+	}
+	if node.Kind == yaml.ScalarNode {
+		return node.Tag == "!!str" && strings.Contains(node.Value, translateDirectiveTag)
+	}
+	for _, child := range node.Content {
+		if hasMessageReference(child) {
+			return true
+		}
+	}
+	return false
+}
+
+// translateNode replaces message references in place.
+func translateNode(node *yaml.Node, lookup func(string) (string, bool)) {
+	if key, ok := messageMapping(node); ok {
+		message, found := lookup(key)
+		if !found {
+			message = key
+			if fallback := mappingValue(node, i18nDefaultKey); fallback != nil {
+				message = fallback.Value
+			}
+		}
+		*node = yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: message, Line: node.Line, Column: node.Column}
+		return
+	}
+	if node.Kind == yaml.ScalarNode {
+		if node.Tag != "!!str" || !strings.Contains(node.Value, translateDirectiveTag) {
+			return
+		}
+		node.Value = translateDirective.ReplaceAllStringFunc(node.Value, func(match string) string {
+			key := translateDirective.FindStringSubmatch(match)[1]
+			if message, ok := lookup(key); ok {
+				return message
+			}
+			return key
+		})
+		node.Style = 0
+		return
+	}
+	for _, child := range node.Content {
+		translateNode(child, lookup)
+	}
+}
+
+// messageMapping reports whether node is {i18n: key} with an optional
+// default.
+func messageMapping(node *yaml.Node) (string, bool) {
+	if node.Kind != yaml.MappingNode || len(node.Content) == 0 || len(node.Content) > 4 {
+		return "", false
+	}
+	key := ""
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		value := node.Content[i+1]
+		switch node.Content[i].Value {
+		case i18nKey:
+			if value.Kind != yaml.ScalarNode || strings.TrimSpace(value.Value) == "" {
+				return "", false
+			}
+			key = strings.TrimSpace(value.Value)
+		case i18nDefaultKey:
+			if value.Kind != yaml.ScalarNode {
+				return "", false
+			}
+		default:
+			return "", false
+		}
+	}
+	return key, key != ""
+}
+
+// LocaleChain returns the catalog lookup order for value, a language tag or
+// an Accept-Language header: preferences by quality, each followed by its
+// parent tags, then the default locale and its parents.
+//
+//	LocaleChain("pt-BR,fr;q=0.5", "en") // [pt-BR pt fr en]
+func LocaleChain(value, defaultLocale string) []string {
+	type preference struct {
+		tag     string
+		quality float64
+	}
+	var preferences []preference
+	for _, part := range strings.Split(value, ",") {
+		tag, params, _ := strings.Cut(part, ";")
+		tag = canonicalLocale(tag)
+		if tag == "" || tag == "*" {
+			continue
+		}
+		quality := 1.0
+		for _, param := range strings.Split(params, ";") {
+			name, raw, ok := strings.Cut(strings.TrimSpace(param), "=")
+			if ok && strings.TrimSpace(name) == "q" {
+				if parsed, err := strconv.ParseFloat(strings.TrimSpace(raw), 64); err == nil {
+					quality = parsed
+				}
+			}
+		}
+		if quality > 0 {
+			preferences = append(preferences, preference{tag: tag, quality: quality})
+		}
+	}
+	sort.SliceStable(preferences, func(i, j int) bool {
+		return preferences[i].quality > preferences[j].quality
+	})
+	var result []string
+	for _, item := range preferences {
+		result = append(result, localeParents(item.tag)...)
+	}
+	if tag := canonicalLocale(defaultLocale); tag != "" {
+		result = append(result, localeParents(tag)...)
+	}
+	return uniqueStrings(result)
+}
+
+// localeParents returns tag followed by its truncations: zh-Hant-TW, zh-Hant, zh.
+func localeParents(tag string) []string {
+	result := []string{tag}
+	for index := strings.LastIndex(tag, "-"); index > 0; index = strings.LastIndex(tag, "-") {
+		tag = tag[:index]
+		result = append(result, tag)
+	}
+	return result
+}
+
+// canonicalLocale normalizes a BCP 47 tag's case: language lower, script
+// title and region upper case; underscores are accepted as separators.
+// Malformed tags yield "".
+func canonicalLocale(tag string) string {
+	tag = strings.TrimSpace(strings.ReplaceAll(tag, "_", "-"))
+	if tag == "" || tag == "*" {
+		return tag
+	}
+	if !localeTag.MatchString(tag) {
+		return ""
+	}
+	parts := strings.Split(tag, "-")
+	for i, part := range parts {
+		switch {
+		case i == 0:
+			parts[i] = strings.ToLower(part)
+		case len(part) == 4:
+			parts[i] = strings.ToUpper(part[:1]) + strings.ToLower(part[1:])
+		case len(part) == 2 || (len(part) == 3 && part[0] >= '0' && part[0] <= '9'):
+			parts[i] = strings.ToUpper(part)
+		default:
+			parts[i] = strings.ToLower(part)
+		}
+	}
+	return strings.Join(parts, "-")
+}
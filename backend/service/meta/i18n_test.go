@@ -0,0 +1,85 @@
+package meta
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/viant/afs"
+	"github.com/viant/forge/backend/types"
+)
+
+func TestLoadForTarget_LocalizesMessageReferences(t *testing.T) {
+	root := t.TempDir()
+	mustWriteMetaFile(t, filepath.Join(root, "i18n", "en.yaml"), "orders:\n  title: Orders\n  total: Total\n  region: Region\n")
+	mustWriteMetaFile(t, filepath.Join(root, "i18n", "pt.yaml"), "orders:\n  title: Pedidos\n  total: Total geral\n")
+	mustWriteMetaFile(t, filepath.Join(root, "i18n", "pt-BR.yaml"), "orders:\n  title: Pedidos (BR)\n")
+	mustWriteMetaFile(t, filepath.Join(root, "order.yaml"), `namespace: order
+view:
+  content:
+    title: $t(orders.title)
+    table:
+      columns:
+        - id: total
+          name: "Sum: $t(orders.total)"
+        - id: region
+          name: {i18n: orders.region}
+        - id: missing
+          name: {i18n: orders.missing, default: Unknown}
+`)
+	mustWriteMetaFile(t, filepath.Join(root, "title.yaml"), "namespace: title\nview:\n  content:\n    title: $t(orders.title)\n")
+	service := New(afs.New(), root)
+	window := &types.Window{}
+	if err := service.LoadForTarget(context.Background(), "title.yaml", window, &TargetContext{Platform: "web", Locale: "pt-BR"}); err != nil {
+		t.Fatalf("LoadForTarget() error = %v", err)
+	}
+	if window.View.Content.Title != "$t(orders.title)" {
+		t.Fatalf("expected references to stay unresolved without ConfigureI18n, got %q", window.View.Content.Title)
+	}
+
+	service.ConfigureI18n()
+	window = &types.Window{}
+	target := &TargetContext{Platform: "web", Locale: "pt-br, fr;q=0.4"}
+	if err := service.LoadForTarget(context.Background(), "order.yaml", window, target); err != nil {
+		t.Fatalf("LoadForTarget() error = %v", err)
+	}
+	if window.View.Content.Title != "Pedidos (BR)" {
+		t.Fatalf("expected regional title, got %q", window.View.Content.Title)
+	}
+	var names []string
+	for _, column := range window.View.Content.Table.Columns {
+		names = append(names, column.Name)
+	}
+	expected := []string{"Sum: Total geral", "Region", "Unknown"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Fatalf("expected %v, got %v", expected, names)
+	}
+
+	window = &types.Window{}
+	if err := service.LoadForTarget(context.Background(), "order.yaml", window, &TargetContext{Platform: "web"}); err != nil {
+		t.Fatalf("LoadForTarget() error = %v", err)
+	}
+	if window.View.Content.Title != "Orders" {
+		t.Fatalf("expected default locale title, got %q", window.View.Content.Title)
+	}
+}
+
+func TestLocaleChain(t *testing.T) {
+	testCases := []struct {
+		value    string
+		expected []string
+	}{
+		{value: "", expected: []string{"en"}},
+		{value: "pt_br", expected: []string{"pt-BR", "pt", "en"}},
+		{value: "fr;q=0.5, zh-hant-tw, *;q=0.1, de;q=0", expected: []string{"zh-Hant-TW", "zh-Hant", "zh", "fr", "en"}},
+		{value: "en-GB,en;q=0.8", expected: []string{"en-GB", "en"}},
+		{value: "../../secrets/x, de;q=0.5", expected: []string{"de", "en"}},
+		{value: "pt/../../x,en.yaml", expected: []string{"en"}},
+	}
+	for _, testCase := range testCases {
+		if actual := LocaleChain(testCase.value, "en"); !reflect.DeepEqual(actual, testCase.expected) {
+			t.Fatalf("LocaleChain(%q) expected %v, got %v", testCase.value, testCase.expected, actual)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package meta
+
+import (
+	"context"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/viant/afs"
+	"github.com/viant/afs/file"
+	"github.com/viant/afs/storage"
+	"github.com/viant/afs/url"
+)
+
+// NewWithRoots creates a Service that resolves metadata through an ordered
+// overlay chain, e.g. a tenant overlay, then a product bundle, then built-in
+// defaults:
+//
+//	meta.NewWithRoots(fs, []string{"gs://tenant/acme", "gs://bundle/crm", "file:///opt/forge/defaults"})
+//
+// Every file lookup, including windows, navigation and $import targets, takes
+// the root-relative path of the requested file and uses the first root that
+// holds it, so an overlay may override a single window or fragment. Relative
+// paths are joined with the first root.
+func NewWithRoots(fs afs.Service, roots []string, options ...storage.Option) *Service {
+	result := &Service{fs: fs, options: options}
+	for _, root := range roots {
+		if root = strings.TrimSpace(root); root != "" {
+			result.roots = append(result.roots, root)
+		}
+	}
+	if len(result.roots) > 0 {
+		result.baseURL = result.roots[0]
+	}
+	return result
+}
+
+// Roots returns the overlay chain in priority order.
+func (l *Service) Roots() []string {
+	return append([]string{}, l.roots...)
+}
+
+// locate returns the URL of URL's root-relative path in the first root that
+// holds it. URLs outside the chain, or in a single-root Service, are checked
+// as is.
+func (l *Service) locate(ctx context.Context, URL string) (string, bool, error) {
+	rel, ok := l.rootRelative(URL)
+	if !ok || len(l.roots) < 2 {
+		exists, err := l.fs.Exists(ctx, URL, l.options...)
+		return URL, exists, err
+	}
+	var firstErr error
+	for _, root := range l.roots {
+		candidate := url.Join(root, rel)
+		exists, err := l.fs.Exists(ctx, candidate, l.options...)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		if exists {
+			return candidate, true, nil
+		}
+	}
+	return URL, false, firstErr
+}
+
+// overlayURL returns the overlay location of URL, or URL when no root holds
+// it so the caller reports the original location.
+func (l *Service) overlayURL(ctx context.Context, URL string) string {
+	if len(l.roots) < 2 {
+		return URL
+	}
+	if located, ok, _ := l.locate(ctx, URL); ok {
+		return located
+	}
+	return URL
+}
+
+// rootRelative returns URL relative to the chain root containing it.
+func (l *Service) rootRelative(URL string) (string, bool) {
+	key := overlayKey(URL)
+	for _, root := range l.roots {
+		rootKey := strings.TrimSuffix(overlayKey(root), "/")
+		if key == rootKey {
+			return "", true
+		}
+		if strings.HasPrefix(key, rootKey+"/") {
+			return strings.TrimPrefix(key, rootKey+"/"), true
+		}
+	}
+	return "", false
+}
+
+// overlayKey normalizes URL so scheme-less local paths and file:// URLs of
+// the same location compare equal.
+func overlayKey(URL string) string {
+	scheme := url.Scheme(URL, file.Scheme)
+	if scheme == file.Scheme {
+		if location, ok := localRoot(URL); ok {
+			return file.Scheme + "://" + filepath.ToSlash(location)
+		}
+	}
+	return scheme + "://" + url.Host(URL) + path.Clean("/"+url.Path(URL))
+}
+
+// listOverlay lists URL in every root, keeping the first entry per name.
+func (l *Service) listOverlay(ctx context.Context, URL string) ([]storage.Object, error) {
+	rel, ok := l.rootRelative(URL)
+	if !ok || len(l.roots) < 2 {
+		return l.fs.List(ctx, URL, l.options...)
+	}
+	var result []storage.Object
+	var firstErr error
+	listed := false
+	seen := map[string]bool{}
+	for _, root := range l.roots {
+		location := url.Join(root, rel)
+		objects, err := l.fs.List(ctx, location, l.options...)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		listed = true
+		for _, object := range objects {
+			if url.Equals(location, object.URL()) || seen[object.Name()] {
+				continue
+			}
+			seen[object.Name()] = true
+			result = append(result, object)
+		}
+	}
+	if !listed {
+		return nil, firstErr
+	}
+	return result, nil
+}
@@ -0,0 +1,95 @@
+package meta
+
+import (
+	"context"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/viant/afs"
+)
+
+func TestNewWithRoots_OverlayShadowsWindowsAndImports(t *testing.T) {
+	tenant, defaults := t.TempDir(), t.TempDir()
+	mustWriteMetaFile(t, filepath.Join(defaults, "order", "shared", "main.yaml"), "namespace: order\nview:\n  content:\n    table: $import(table.yaml)\n    title: $import(title.yaml)\n")
+	mustWriteMetaFile(t, filepath.Join(defaults, "order", "shared", "table.yaml"), "id: default\n")
+	mustWriteMetaFile(t, filepath.Join(defaults, "order", "shared", "title.yaml"), "id: default\n")
+	mustWriteMetaFile(t, filepath.Join(defaults, "customer", "shared", "main.yaml"), "namespace: customer\n")
+	mustWriteMetaFile(t, filepath.Join(tenant, "order", "shared", "table.yaml"), "id: tenant\n")
+	mustWriteMetaFile(t, filepath.Join(tenant, "customer", "shared", "main.yaml"), "namespace: acme\n")
+
+	service := NewWithRoots(afs.New(), []string{tenant, defaults})
+	ctx := context.Background()
+	target := &TargetContext{Platform: "web", FormFactor: "desktop"}
+
+	base, err := service.ResolveWindowBase(ctx, "order/main", target)
+	if err != nil || base != "order/shared/main" {
+		t.Fatalf("unexpected base %q err=%v", base, err)
+	}
+	var order struct {
+		View struct {
+			Content struct {
+				Table struct{ ID string } `yaml:"table"`
+				Title struct{ ID string } `yaml:"title"`
+			} `yaml:"content"`
+		} `yaml:"view"`
+	}
+	if err := service.LoadForTarget(ctx, base+".yaml", &order, target); err != nil {
+		t.Fatalf("LoadForTarget() error = %v", err)
+	}
+	if order.View.Content.Table.ID != "tenant" || order.View.Content.Title.ID != "default" {
+		t.Fatalf("expected tenant table and default title, got %+v", order.View.Content)
+	}
+
+	var customer struct{ Namespace string }
+	if err := service.LoadForTarget(ctx, "customer/shared/main.yaml", &customer, target); err != nil {
+		t.Fatalf("LoadForTarget() error = %v", err)
+	}
+	if customer.Namespace != "acme" {
+		t.Fatalf("expected tenant window, got %q", customer.Namespace)
+	}
+
+	graph, err := service.ImportGraph(ctx, "order/shared/main.yaml", target)
+	if err != nil {
+		t.Fatalf("ImportGraph() error = %v", err)
+	}
+	var fromTenant []string
+	for _, source := range graph.Files {
+		if strings.Contains(source, filepath.ToSlash(tenant)) {
+			fromTenant = append(fromTenant, source[strings.LastIndex(source, "/")+1:])
+		}
+	}
+	if len(fromTenant) != 1 || fromTenant[0] != "table.yaml" {
+		t.Fatalf("expected only table.yaml from the tenant root, got %v (files %v)", fromTenant, graph.Files)
+	}
+}
+
+func TestNewWithRoots_ListMergesRoots(t *testing.T) {
+	tenant, defaults := t.TempDir(), t.TempDir()
+	mustWriteMetaFile(t, filepath.Join(defaults, "order", "a.yaml"), "id: a\n")
+	mustWriteMetaFile(t, filepath.Join(defaults, "order", "b.yaml"), "id: b\n")
+	mustWriteMetaFile(t, filepath.Join(tenant, "order", "b.yaml"), "id: b\n")
+	mustWriteMetaFile(t, filepath.Join(tenant, "order", "c.yaml"), "id: c\n")
+
+	service := NewWithRoots(afs.New(), []string{tenant, defaults})
+	items, err := service.List(context.Background(), "order")
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	var names []string
+	for _, item := range items {
+		name := item[strings.LastIndex(item, "/")+1:]
+		if name == "b.yaml" && !strings.Contains(item, filepath.ToSlash(tenant)) {
+			t.Fatalf("expected b.yaml from the tenant root, got %s", item)
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if strings.Join(names, ",") != "a.yaml,b.yaml,c.yaml" {
+		t.Fatalf("expected merged listing, got %v", names)
+	}
+	if exists, err := service.Exists(context.Background(), "order/a.yaml"); err != nil || !exists {
+		t.Fatalf("expected order/a.yaml to exist through the chain, got %v err=%v", exists, err)
+	}
+}
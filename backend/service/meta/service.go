@@ -7,6 +7,7 @@ import (
 	"github.com/viant/afs/file"
 	"github.com/viant/afs/storage"
 	"github.com/viant/afs/url"
+	"github.com/viant/forge/backend/service/identity"
 	"gopkg.in/yaml.v3"
 	"path"
 	"strconv"
@@ -17,7 +18,12 @@ import (
 type Service struct {
 	fs      afs.Service
 	baseURL string
+	roots   []string
 	options []storage.Option
+	cache   *cache
+	strict  *validator
+	i18n    *i18n
+	flags   FlagProvider
 }
 
 type TargetContext struct {
@@ -25,6 +31,24 @@ type TargetContext struct {
 	FormFactor   string
 	Surface      string
 	Capabilities []string
+	// AppVersion is the client application version TargetSpec appVersion
+	// ranges are matched against; see MatchesVersion.
+	AppVersion string
+	// Locale is a language tag or an Accept-Language value; see LocaleChain.
+	Locale string
+	// Identity is the caller visibleFor/requires rules are evaluated for;
+	// nil is anonymous.
+	Identity *identity.Identity
+	// Flags are the enabled feature flags TargetSpec flag expressions are
+	// evaluated against; see EnableFlags.
+	Flags []string
+}
+
+func (t *TargetContext) caller() *identity.Identity {
+	if t == nil {
+		return nil
+	}
+	return t.Identity
 }
 
 // Load reads the YAML file at the given path, resolves $import directives,
@@ -43,42 +67,142 @@ func (l *Service) LoadWithURL(ctx context.Context, URL string, v interface{}) er
 }
 
 func (l *Service) LoadWithURLAndTarget(ctx context.Context, URL string, v interface{}, target *TargetContext) error {
+	node, err := l.loadNode(ctx, URL, target)
+	if err != nil {
+		return err
+	}
+	// Decode the resolved YAML node into the provided Go variable.
+	return decodeNode(URL, node, v)
+}
+
+// LoadForTarget loads path like LoadWithTarget, then applies target matching
+// and targetOverrides (see ApplyTarget) so only nodes for target are decoded.
+// Nodes the caller may not see are removed (see ApplyAccess) and message
+// references are localized for target.Locale (see ConfigureI18n).
+// With EnableFlags target flags are resolved before matching, and with
+// EnableStrict the document is validated first (see ValidationError).
+func (l *Service) LoadForTarget(ctx context.Context, path string, v interface{}, target *TargetContext) error {
+	URL := l.getURL(path)
+	target, err := l.withFlags(ctx, target)
+	if err != nil {
+		return err
+	}
+	if l.strict != nil {
+		return l.loadStrict(ctx, URL, v, target)
+	}
+	node, err := l.loadNode(ctx, URL, target)
+	if err != nil {
+		return err
+	}
+	ApplyTarget(node, target)
+	ApplyAccess(node, v, target.caller())
+	if err := l.Localize(ctx, node, target); err != nil {
+		return err
+	}
+	return decodeNode(URL, node, v)
+}
+
+// decodeNode decodes node into v, reporting type errors as a LoadError.
+func decodeNode(URL string, node *yaml.Node, v interface{}) error {
+	if err := node.Decode(v); err != nil {
+		line, column := yamlErrorPosition(err)
+		return &LoadError{URL: URL, Line: line, Column: column, Err: err}
+	}
+	return nil
+}
+
+// loadNode returns the YAML node for URL with $import directives resolved,
+// served from the cache when enabled.
+func (l *Service) loadNode(ctx context.Context, URL string, target *TargetContext) (*yaml.Node, error) {
+	key := cacheKey("node", URL, target)
+	if node, ok := l.cachedNode(ctx, key); ok {
+		return node, nil
+	}
+	generation := l.cacheGeneration()
+	session := newLoadSession(target)
+	node, err := l.resolveNode(ctx, URL, session)
+	if err != nil {
+		return nil, err
+	}
+	l.putNode(ctx, key, generation, node, session.sources)
+	return node, nil
+}
+
+// resolveNode reads URL and resolves its $import directives.
+func (l *Service) resolveNode(ctx context.Context, URL string, session *loadSession) (*yaml.Node, error) {
+	URL = l.overlayURL(ctx, URL)
 	// Read the file content using the filesystem service.
 	data, err := l.fs.DownloadWithURL(ctx, URL, l.options...)
 	if err != nil {
-		return err
+		return nil, session.loadError(URL, nil, nil, err)
+	}
+	object, err := l.fs.Object(ctx, URL, l.options...)
+	if err != nil {
+		return nil, session.loadError(URL, nil, nil, err)
 	}
-	object, _ := l.fs.Object(ctx, URL, l.options)
 
 	// Parse the YAML into a yaml.Node.
 	var node yaml.Node
 	if err := yaml.Unmarshal(data, &node); err != nil {
-		return err
+		return nil, session.loadError(URL, nil, nil, err)
 	}
 
 	// Resolve $import directives recursively.
 	baseDir, _ := url.Split(object.URL(), file.Scheme)
 
-	if err := l.resolveImports(ctx, &node, baseDir, target); err != nil {
-		return err
+	session.addSource(object.URL())
+	if err := session.enter(object.URL()); err != nil {
+		return nil, session.loadError(URL, nil, nil, err)
 	}
+	defer session.leave()
+	if err := l.resolveImports(ctx, &node, baseDir, session); err != nil {
+		return nil, err
+	}
+	return &node, nil
+}
 
-	// Decode the resolved YAML node into the provided Go variable.
-	err = node.Decode(v)
+// loadSession carries per-load state through $import resolution.
+type loadSession struct {
+	target  *TargetContext
+	sources []string
+	stack   []string
+	edges   []ImportEdge
+	origins map[*yaml.Node]string
+}
+
+func newLoadSession(target *TargetContext) *loadSession {
+	return &loadSession{target: target}
+}
+
+// setOrigin records the file an imported node was read from. Only the
+// replaced node is recorded; its descendants inherit the origin.
+func (s *loadSession) setOrigin(node *yaml.Node, URL string) {
+	if s.origins == nil {
+		s.origins = map[*yaml.Node]string{}
+	}
+	s.origins[node] = URL
+}
 
-	return err
+// addSource records a file URL that contributed to the loaded node.
+func (s *loadSession) addSource(URL string) {
+	for _, source := range s.sources {
+		if source == URL {
+			return
+		}
+	}
+	s.sources = append(s.sources, URL)
 }
 
 func (l *Service) Exists(ctx context.Context, path string) (bool, error) {
 	URL := l.getURL(path)
-
-	return l.fs.Exists(ctx, URL, l.options...)
+	_, exists, err := l.locate(ctx, URL)
+	return exists, err
 }
 
 func (l *Service) List(ctx context.Context, path string) ([]string, error) {
 	var result []string
 	URL := l.getURL(path)
-	objects, err := l.fs.List(ctx, URL, l.options...)
+	objects, err := l.listOverlay(ctx, URL)
 	if err != nil {
 		return nil, err
 	}
@@ -92,31 +216,34 @@ func (l *Service) List(ctx context.Context, path string) ([]string, error) {
 }
 
 func (l *Service) Download(ctx context.Context, path string) ([]byte, error) {
-	URL := l.getURL(path)
+	URL := l.overlayURL(ctx, l.getURL(path))
 	return l.fs.DownloadWithURL(ctx, URL, l.options...)
 }
 
 // resolveImports recursively resolves $import directives within a YAML node.
-func (l *Service) resolveImports(ctx context.Context, node *yaml.Node, baseDir string, target *TargetContext) error {
+func (l *Service) resolveImports(ctx context.Context, node *yaml.Node, baseDir string, session *loadSession) error {
 	switch node.Kind {
 	case yaml.DocumentNode:
 		// Recursively resolve imports in document content.
 		for _, contentNode := range node.Content {
-			if err := l.processNode(ctx, contentNode, baseDir, target); err != nil {
+			if err := l.processNode(ctx, contentNode, baseDir, session); err != nil {
 				return err
 			}
 		}
 	case yaml.MappingNode:
+		if mappingValue(node, extendKey) != nil {
+			return l.resolveExtend(ctx, node, baseDir, session)
+		}
 		// Mapping nodes have content in key-value pairs.
 		for i := 0; i < len(node.Content); i += 2 {
 			keyNode := node.Content[i]
 			valueNode := node.Content[i+1]
 
 			// Resolve imports in the key and value nodes.
-			if err := l.processNode(ctx, keyNode, baseDir, target); err != nil {
+			if err := l.processNode(ctx, keyNode, baseDir, session); err != nil {
 				return err
 			}
-			if err := l.processNode(ctx, valueNode, baseDir, target); err != nil {
+			if err := l.processNode(ctx, valueNode, baseDir, session); err != nil {
 				return err
 			}
 		}
@@ -124,14 +251,14 @@ func (l *Service) resolveImports(ctx context.Context, node *yaml.Node, baseDir s
 		// Resolve imports in each item of the sequence.
 		for i := 0; i < len(node.Content); i++ {
 			itemNode := node.Content[i]
-			if err := l.processNode(ctx, itemNode, baseDir, target); err != nil {
+			if err := l.processNode(ctx, itemNode, baseDir, session); err != nil {
 				return err
 			}
 		}
 	case yaml.AliasNode:
 		// Resolve imports in the referenced node.
 		if node.Alias != nil {
-			if err := l.resolveImports(ctx, node.Alias, baseDir, target); err != nil {
+			if err := l.resolveImports(ctx, node.Alias, baseDir, session); err != nil {
 				return err
 			}
 		}
@@ -142,57 +269,92 @@ func (l *Service) resolveImports(ctx context.Context, node *yaml.Node, baseDir s
 }
 
 // processNode checks if the node contains an $import directive and processes it.
-func (l *Service) processNode(ctx context.Context, node *yaml.Node, baseDir string, target *TargetContext) error {
+func (l *Service) processNode(ctx context.Context, node *yaml.Node, baseDir string, session *loadSession) error {
 	if node.Kind == yaml.ScalarNode && node.Tag == "!!str" {
 		if isImportDirective(node.Value) {
-			importPath, key, err := getImportPathAndKey(node.Value)
-			if err != nil {
-				return err
-			}
-			fullPath, err := l.resolveImportURL(ctx, baseDir, importPath, target)
+			from := session.current()
+			directive, err := parseImportDirective(node.Value)
 			if err != nil {
-				return err
+				return session.loadError(from, node, nil, err)
 			}
-			data, err := l.fs.DownloadWithURL(ctx, fullPath, l.options...)
+			importPath, key := directive.Path, directive.Key
+			fullPath, candidates, err := l.resolveImportURL(ctx, baseDir, importPath, session.target)
 			if err != nil {
-				return err
+				return session.loadError(from, node, candidates, err)
 			}
-			var importedNode yaml.Node
-			if err := yaml.Unmarshal(data, &importedNode); err != nil {
-				return err
+			session.addEdge(ImportEdge{
+				From:       from,
+				Directive:  strings.TrimSpace(node.Value),
+				Path:       importPath,
+				Key:        key,
+				Arguments:  directive.argumentMap(),
+				Resolved:   fullPath,
+				Candidates: candidates,
+				Line:       node.Line,
+				Column:     node.Column,
+			})
+			if err := session.enter(fullPath); err != nil {
+				return session.loadError(from, node, candidates, err)
 			}
-			parent, _ := url.Split(fullPath, file.Scheme)
-			// Resolve imports in the imported node recursively.
-			if err := l.resolveImports(ctx, &importedNode, parent, target); err != nil {
+			importedNode, err := l.loadImport(ctx, fullPath, directive.Arguments, session)
+			session.leave()
+			if err != nil {
 				return err
 			}
 
 			var replacementNode *yaml.Node
 			if key == "" {
 				// No specific key requested; use the entire imported content.
-				replacementNode = getContentNode(&importedNode)
+				replacementNode = getContentNode(importedNode)
 			} else {
 				// Extract the node under the specified key.
-				extractedNode, err := getNodeByKey(&importedNode, key)
+				extractedNode, err := getNodeByKey(importedNode, key)
 				if err != nil {
-					return err
+					return session.loadError(from, node, candidates, fmt.Errorf("%s: %w", fullPath, err))
 				}
 				replacementNode = extractedNode
 			}
 
 			// Replace the current node with the imported content or the extracted node.
 			*node = *replacementNode
+			session.setOrigin(node, fullPath)
 		}
 	} else {
 		// Recursively resolve imports in this node.
-		if err := l.resolveImports(ctx, node, baseDir, target); err != nil {
+		if err := l.resolveImports(ctx, node, baseDir, session); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
+// loadImport downloads and resolves an imported file already pushed onto the
+// session import stack. Directive arguments are substituted before nested
+// imports resolve, so they can be forwarded to them.
+func (l *Service) loadImport(ctx context.Context, fullPath string, arguments []importArgument, session *loadSession) (*yaml.Node, error) {
+	data, err := l.fs.DownloadWithURL(ctx, fullPath, l.options...)
+	if err != nil {
+		return nil, session.loadError(fullPath, nil, nil, err)
+	}
+	session.addSource(fullPath)
+	var importedNode yaml.Node
+	if err := yaml.Unmarshal(data, &importedNode); err != nil {
+		return nil, session.loadError(fullPath, nil, nil, err)
+	}
+	substituteImportArguments(&importedNode, arguments)
+	parent, _ := url.Split(fullPath, file.Scheme)
+	// Resolve imports in the imported node recursively.
+	if err := l.resolveImports(ctx, &importedNode, parent, session); err != nil {
+		return nil, err
+	}
+	return &importedNode, nil
+}
+
 func (l *Service) ResolveWindowBase(ctx context.Context, basePath string, target *TargetContext) (string, error) {
+	key := cacheKey("base", basePath, target)
+	if resolved, ok := l.cachedPath(key); ok {
+		return resolved, nil
+	}
 	candidates := branchCandidates(basePath, target)
 	for _, candidate := range candidates {
 		ok, err := l.Exists(ctx, candidate+".yaml")
@@ -200,10 +362,11 @@ func (l *Service) ResolveWindowBase(ctx context.Context, basePath string, target
 			continue
 		}
 		if ok {
+			l.putPath(key, candidate)
 			return candidate, nil
 		}
 	}
-	return "", fmt.Errorf("open %s.yaml: file does not exist", basePath)
+	return "", newBranchError(basePath, ".yaml", candidates)
 }
 
 func (l *Service) ResolveWindowAsset(ctx context.Context, basePath, ext string, target *TargetContext) (string, error) {
@@ -214,6 +377,10 @@ func (l *Service) ResolveWindowAsset(ctx context.Context, basePath, ext string,
 	if !strings.HasPrefix(extension, ".") {
 		extension = "." + extension
 	}
+	key := cacheKey("asset", basePath+extension, target)
+	if resolved, ok := l.cachedPath(key); ok {
+		return resolved, nil
+	}
 	baseDir, leaf := splitMetaBase(basePath)
 	candidates := make([]string, 0)
 	if target == nil {
@@ -235,24 +402,28 @@ func (l *Service) ResolveWindowAsset(ctx context.Context, basePath, ext string,
 			continue
 		}
 		if ok {
+			l.putPath(key, candidate+extension)
 			return candidate + extension, nil
 		}
 	}
-	return "", fmt.Errorf("open %s%s: file does not exist", basePath, extension)
+	return "", newBranchError(basePath, extension, candidates)
 }
 
-func (l *Service) resolveImportURL(ctx context.Context, baseDir, importPath string, target *TargetContext) (string, error) {
+// resolveImportURL returns the first existing import candidate together with
+// every candidate considered, in branch order. Each candidate is looked up
+// through the overlay chain.
+func (l *Service) resolveImportURL(ctx context.Context, baseDir, importPath string, target *TargetContext) (string, []string, error) {
 	candidates := importCandidates(baseDir, importPath, target)
 	for _, candidate := range candidates {
-		ok, err := l.fs.Exists(ctx, candidate, l.options...)
+		located, ok, err := l.locate(ctx, candidate)
 		if err != nil {
 			continue
 		}
 		if ok {
-			return candidate, nil
+			return located, candidates, nil
 		}
 	}
-	return "", fmt.Errorf("open %s: file does not exist", url.Join(baseDir, importPath))
+	return "", candidates, fmt.Errorf("open %s: file does not exist", url.Join(baseDir, importPath))
 }
 
 func branchCandidates(basePath string, target *TargetContext) []string {
@@ -375,37 +546,24 @@ func splitMetaBase(basePath string) (string, string) {
 
 func joinMetaPath(base, rel string) string {
 	if url.Scheme(base, "") != "" {
-		return url.Join(base, rel)
+		return cleanURL(url.Join(base, rel))
 	}
 	return path.Clean(path.Join(base, rel))
 }
 
-// getImportPathAndKey extracts the path and key from an $import directive.
-func getImportPathAndKey(value string) (path string, key string, err error) {
-	value = strings.TrimSpace(value)
-	if !isImportDirective(value) {
-		return "", "", fmt.Errorf("not an import directive: %s", value)
-	}
-	// Extract the path inside the parentheses.
-	start := strings.Index(value, "(")
-	end := strings.LastIndex(value, ")")
-	if start == -1 || end == -1 || start >= end {
-		return "", "", fmt.Errorf("invalid import directive syntax: %s", value)
-	}
-	pathValue := strings.TrimSpace(value[start+1 : end])
-	// Remove surrounding quotes if present.
-	pathValue = strings.Trim(pathValue, "\"'")
-
-	// Check if a key is specified after a colon.
-	parts := strings.SplitN(pathValue, ":", 2)
-	path = parts[0]
-	if len(parts) > 1 {
-		key = parts[1]
+// cleanURL collapses "." and ".." segments in the path part of URL so the
+// same file always resolves to one URL.
+func cleanURL(URL string) string {
+	index := strings.Index(URL, "://")
+	if index == -1 {
+		return path.Clean(URL)
 	}
-	if !strings.HasSuffix(path, ".yaml") {
-		path += ".yaml"
+	rest := URL[index+3:]
+	slash := strings.Index(rest, "/")
+	if slash == -1 {
+		return URL
 	}
-	return path, key, nil
+	return URL[:index+3] + rest[:slash] + path.Clean(rest[slash:])
 }
 
 // isImportDirective checks if a string is an $import directive.
@@ -483,5 +641,5 @@ func getContentNode(node *yaml.Node) *yaml.Node {
 
 // New creates a new Service with the provided filesystem service.
 func New(fs afs.Service, baseURL string, options ...storage.Option) *Service {
-	return &Service{fs: fs, baseURL: baseURL, options: options}
+	return NewWithRoots(fs, []string{baseURL}, options...)
 }
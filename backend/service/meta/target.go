@@ -0,0 +1,249 @@
+package meta
+
+import (
+	"strings"
+
+	"github.com/viant/forge/backend/types"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	targetKey          = "target"
+	targetOverridesKey = "targetOverrides"
+)
+
+// plainTargetKeys lists mapping keys whose children use "target" as a plain
+// string (TableFormattingRule row|cell, TableLink _blank) rather than a
+// TargetSpec, so they must never be pruned as platform targets.
+var plainTargetKeys = map[string]bool{
+	"formattingRules": true,
+	"link":            true,
+}
+
+// ApplyTarget resolves target metadata in place the same way the frontend
+// metadata resolver does: nodes whose target does not match are removed,
+// matching targetOverrides entries are deep-merged in key order, and the
+// consumed target/targetOverrides keys are dropped. Containers requiring
+// capabilities the target lacks are replaced by their fallback. A nil
+// target is a no-op.
+func ApplyTarget(node *yaml.Node, target *TargetContext) {
+	if node == nil || target == nil {
+		return
+	}
+	applyTarget(node, target, true)
+}
+
+// MatchesTarget reports whether spec applies to the target context.
+// A nil or empty spec matches every target.
+func MatchesTarget(spec *types.TargetSpec, target *TargetContext) bool {
+	if spec == nil {
+		return true
+	}
+	if target == nil {
+		target = &TargetContext{}
+	}
+	platform := strings.TrimSpace(target.Platform)
+	formFactor := strings.TrimSpace(target.FormFactor)
+	if len(spec.Platforms) > 0 && (platform == "" || !containsString(spec.Platforms, platform)) {
+		return false
+	}
+	if len(spec.ExcludePlatforms) > 0 && platform != "" && containsString(spec.ExcludePlatforms, platform) {
+		return false
+	}
+	if len(spec.FormFactors) > 0 && (formFactor == "" || !containsString(spec.FormFactors, formFactor)) {
+		return false
+	}
+	for _, capability := range spec.Capabilities {
+		if !containsString(target.Capabilities, capability) {
+			return false
+		}
+	}
+	if spec.AppVersion != "" && !MatchesVersion(spec.AppVersion, target.AppVersion) {
+		return false
+	}
+	return len(spec.Flags) == 0 || matchesFlags(spec.Flags, target.Flags)
+}
+
+// TargetOverrideKeys returns targetOverrides keys applicable to target, in
+// merge order (later keys win).
+func TargetOverrideKeys(target *TargetContext) []string {
+	if target == nil {
+		return nil
+	}
+	platform := strings.TrimSpace(target.Platform)
+	formFactor := strings.TrimSpace(target.FormFactor)
+	surface := strings.TrimSpace(target.Surface)
+	isMobile := platform == "android" || platform == "ios" || formFactor == "phone" || formFactor == "tablet" || formFactor == "foldable"
+	var result []string
+	if surface != "" {
+		result = append(result, "surface:"+surface, surface)
+	}
+	if isMobile {
+		result = append(result, "mobile")
+	}
+	if formFactor != "" {
+		result = append(result, "formFactor:"+formFactor, formFactor)
+	}
+	if platform != "" {
+		result = append(result, platform)
+	}
+	if isMobile && formFactor != "" {
+		result = append(result, "mobile."+formFactor, "mobile:"+formFactor, "mobile/"+formFactor)
+	}
+	if platform != "" && formFactor != "" {
+		result = append(result, platform+"."+formFactor, platform+"/"+formFactor, platform+":"+formFactor,
+			platform+strings.ToUpper(formFactor[:1])+formFactor[1:])
+	}
+	return uniqueStrings(result)
+}
+
+// applyTarget returns false when node should be removed from its parent.
+func applyTarget(node *yaml.Node, target *TargetContext, targetAware bool) bool {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, item := range node.Content {
+			applyTarget(item, target, true)
+		}
+	case yaml.SequenceNode:
+		content := node.Content[:0]
+		for _, item := range node.Content {
+			if applyTarget(item, target, targetAware) {
+				content = append(content, item)
+			}
+		}
+		node.Content = content
+	case yaml.MappingNode:
+		if targetAware {
+			if !resolveTargetMapping(node, target) || !resolveCapabilities(node, target) {
+				return false
+			}
+		}
+		content := node.Content[:0]
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			keyNode, valueNode := node.Content[i], node.Content[i+1]
+			if !applyTarget(valueNode, target, !plainTargetKeys[keyNode.Value]) {
+				continue
+			}
+			content = append(content, keyNode, valueNode)
+		}
+		node.Content = content
+	}
+	return true
+}
+
+// resolveTargetMapping evaluates the mapping's own target and overrides.
+func resolveTargetMapping(node *yaml.Node, target *TargetContext) bool {
+	if specNode := mappingValue(node, targetKey); specNode != nil {
+		spec, ok := decodeTargetSpec(specNode)
+		if ok && !MatchesTarget(spec, target) {
+			return false
+		}
+	}
+	if overrides := mappingValue(node, targetOverridesKey); overrides != nil && overrides.Kind == yaml.MappingNode {
+		for _, key := range TargetOverrideKeys(target) {
+			if override := mappingValue(overrides, key); override != nil && override.Kind == yaml.MappingNode {
+				mergeMapping(node, override)
+			}
+		}
+	}
+	if specNode := mappingValue(node, targetKey); specNode != nil {
+		if _, ok := decodeTargetSpec(specNode); ok {
+			removeMappingKey(node, targetKey)
+		}
+	}
+	if overrides := mappingValue(node, targetOverridesKey); overrides != nil && isOverrideMapping(overrides) {
+		removeMappingKey(node, targetOverridesKey)
+	}
+	return true
+}
+
+func decodeTargetSpec(node *yaml.Node) (*types.TargetSpec, bool) {
+	spec := &types.TargetSpec{}
+	if err := node.Decode(spec); err != nil {
+		return nil, false
+	}
+	if len(spec.Platforms) == 0 && len(spec.ExcludePlatforms) == 0 && len(spec.FormFactors) == 0 && len(spec.Capabilities) == 0 && len(spec.Flags) == 0 && spec.AppVersion == "" {
+		return nil, false
+	}
+	return spec, true
+}
+
+func isOverrideMapping(node *yaml.Node) bool {
+	if node.Kind != yaml.MappingNode || len(node.Content) == 0 {
+		return false
+	}
+	for i := 1; i < len(node.Content); i += 2 {
+		if node.Content[i].Kind != yaml.MappingNode {
+			return false
+		}
+	}
+	return true
+}
+
+// mergeMapping deep-merges override into base; nested mappings merge and
+// every other value (including sequences) replaces the base value.
+func mergeMapping(base, override *yaml.Node) {
+	for i := 0; i+1 < len(override.Content); i += 2 {
+		key, value := override.Content[i], override.Content[i+1]
+		current := mappingValue(base, key.Value)
+		if current != nil && current.Kind == yaml.MappingNode && value.Kind == yaml.MappingNode {
+			mergeMapping(current, value)
+			continue
+		}
+		setMappingValue(base, key.Value, cloneNode(value))
+	}
+}
+
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+func setMappingValue(node *yaml.Node, key string, value *yaml.Node) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			node.Content[i+1] = value
+			return
+		}
+	}
+	node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, value)
+}
+
+func removeMappingKey(node *yaml.Node, key string) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			node.Content = append(node.Content[:i], node.Content[i+2:]...)
+			return
+		}
+	}
+}
+
+func cloneNode(node *yaml.Node) *yaml.Node {
+	if node == nil {
+		return nil
+	}
+	result := *node
+	if len(node.Content) > 0 {
+		result.Content = make([]*yaml.Node, len(node.Content))
+		for i, item := range node.Content {
+			result.Content[i] = cloneNode(item)
+		}
+	}
+	return &result
+}
+
+func containsString(values []string, candidate string) bool {
+	for _, value := range values {
+		if strings.TrimSpace(value) == candidate {
+			return true
+		}
+	}
+	return false
+}
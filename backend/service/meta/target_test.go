@@ -0,0 +1,127 @@
+package meta
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/viant/afs"
+	"github.com/viant/forge/backend/types"
+)
+
+func TestLoadForTarget_PrunesAndMergesTargetOverrides(t *testing.T) {
+	root := t.TempDir()
+	mustWriteMetaFile(t, filepath.Join(root, "window", "order", "shared", "main.yaml"), `
+namespace: order
+view:
+  content:
+    id: root
+    title: Orders
+    targetOverrides:
+      mobile:
+        title: Orders (mobile)
+        layout:
+          orientation: vertical
+      ios.phone:
+        subtitle: iPhone
+    containers:
+      - id: webOnly
+        target: web
+      - id: mobileOnly
+        target:
+          platforms: [ios, android]
+          formFactors: [phone]
+      - id: charted
+        target:
+          capabilities: [chart]
+      - id: notAndroid
+        target:
+          excludePlatforms: [android]
+      - id: table
+        table:
+          formattingRules:
+            - field: status
+              target: row
+          columns:
+            - id: name
+              name: Name
+              link:
+                href: /orders
+                target: _blank
+`)
+	service := New(afs.New(), filepath.Join(root, "window"))
+
+	var window types.Window
+	if err := service.LoadForTarget(context.Background(), "order/shared/main.yaml", &window, &TargetContext{
+		Platform:   "ios",
+		FormFactor: "phone",
+		Surface:    "app",
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	content := window.View.Content
+	if content == nil {
+		t.Fatalf("expected content")
+	}
+	if content.Title != "Orders (mobile)" || content.Subtitle != "iPhone" {
+		t.Fatalf("expected merged overrides, got title=%q subtitle=%q", content.Title, content.Subtitle)
+	}
+	if content.Layout == nil || content.Layout.Orientation != "vertical" {
+		t.Fatalf("expected merged layout override, got %#v", content.Layout)
+	}
+	if content.TargetOverrides != nil {
+		t.Fatalf("expected targetOverrides to be consumed, got %#v", content.TargetOverrides)
+	}
+	var ids []string
+	for _, child := range content.Containers {
+		ids = append(ids, child.ID)
+		if child.Target != nil {
+			t.Fatalf("expected target to be consumed on %q", child.ID)
+		}
+	}
+	if expected := []string{"mobileOnly", "notAndroid", "table"}; !reflect.DeepEqual(ids, expected) {
+		t.Fatalf("expected containers %v, got %v", expected, ids)
+	}
+	table := content.Containers[2].Table
+	if len(table.FormattingRules) != 1 || table.FormattingRules[0].Target != "row" {
+		t.Fatalf("expected formatting rule target to be preserved, got %#v", table.FormattingRules)
+	}
+	if table.Columns[0].Link == nil || table.Columns[0].Link.Target != "_blank" {
+		t.Fatalf("expected link target to be preserved, got %#v", table.Columns[0].Link)
+	}
+}
+
+func TestLoadForTarget_NilTargetKeepsMetadata(t *testing.T) {
+	root := t.TempDir()
+	mustWriteMetaFile(t, filepath.Join(root, "window", "order", "main.yaml"), `
+namespace: order
+view:
+  content:
+    id: root
+    containers:
+      - id: webOnly
+        target: web
+`)
+	service := New(afs.New(), filepath.Join(root, "window"))
+
+	var window types.Window
+	if err := service.LoadForTarget(context.Background(), "order/main.yaml", &window, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(window.View.Content.Containers) != 1 || window.View.Content.Containers[0].Target == nil {
+		t.Fatalf("expected untouched metadata, got %#v", window.View.Content.Containers)
+	}
+}
+
+func TestTargetOverrideKeys_Order(t *testing.T) {
+	got := TargetOverrideKeys(&TargetContext{Platform: "android", FormFactor: "tablet", Surface: "app"})
+	expected := []string{
+		"surface:app", "app", "mobile", "formFactor:tablet", "tablet", "android",
+		"mobile.tablet", "mobile:tablet", "mobile/tablet",
+		"android.tablet", "android/tablet", "android:tablet", "androidTablet",
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("expected %v, got %v", expected, got)
+	}
+}
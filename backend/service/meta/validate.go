@@ -0,0 +1,643 @@
+package meta
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/viant/forge/backend/types"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// builtinHandlerNamespaces are registered by the frontend for every window.
+var builtinHandlerNamespaces = []string{"window", "dataSource", "dialog"}
+
+// foreignParameterHandlers open another window, so their parameters address
+// that window's data sources rather than this one's.
+var foreignParameterHandlers = map[string]bool{"window.openWindow": true, "window.openTarget": true}
+
+// Diagnostic reports one problem found while validating metadata.
+type Diagnostic struct {
+	Code       string `json:"code"`
+	Severity   string `json:"severity"`
+	Message    string `json:"message"`
+	SourcePath string `json:"sourcePath,omitempty"`
+	YAMLPath   string `json:"yamlPath,omitempty"`
+	Line       int    `json:"line,omitempty"`
+	Column     int    `json:"column,omitempty"`
+}
+
+func (d Diagnostic) Error() string {
+	location := strings.TrimSpace(d.SourcePath)
+	if d.Line > 0 {
+		location += fmt.Sprintf(":%d", d.Line)
+		if d.Column > 0 {
+			location += fmt.Sprintf(":%d", d.Column)
+		}
+	}
+	if d.YAMLPath != "" {
+		location += " " + d.YAMLPath
+	}
+	if location == "" {
+		return d.Message
+	}
+	return location + ": " + d.Message
+}
+
+// ValidationError is returned by strict loads when validation reports at
+// least one error-severity diagnostic.
+type ValidationError struct {
+	URL         string       `json:"url,omitempty"`
+	Diagnostics []Diagnostic `json:"diagnostics"`
+}
+
+func (e *ValidationError) Error() string {
+	if e == nil || len(e.Diagnostics) == 0 {
+		return "metadata validation failed"
+	}
+	if len(e.Diagnostics) == 1 {
+		return e.Diagnostics[0].Error()
+	}
+	return fmt.Sprintf("metadata validation failed with %d issues; first: %s", len(e.Diagnostics), e.Diagnostics[0].Error())
+}
+
+// HasErrors reports whether diagnostics contain an error-severity entry.
+func HasErrors(diagnostics []Diagnostic) bool {
+	for _, diagnostic := range diagnostics {
+		if diagnostic.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateOption customizes metadata validation.
+type ValidateOption func(*validator)
+
+// WithHandlerNamespaces declares handler namespaces the host application
+// registers as services, in addition to window, dataSource, dialog and the
+// window's own ns.
+func WithHandlerNamespaces(namespaces ...string) ValidateOption {
+	return func(v *validator) {
+		v.handlerNamespaces = append(v.handlerNamespaces, namespaces...)
+	}
+}
+
+type validator struct {
+	handlerNamespaces []string
+}
+
+func newValidator(options ...ValidateOption) *validator {
+	result := &validator{}
+	for _, option := range options {
+		if option != nil {
+			option(result)
+		}
+	}
+	return result
+}
+
+// EnableStrict makes LoadForTarget validate metadata before decoding and
+// fail with a ValidationError on unknown keys or dangling references. Strict
+// loads bypass the cache so diagnostics can name the file each node came from.
+func (l *Service) EnableStrict(options ...ValidateOption) *Service {
+	l.strict = newValidator(options...)
+	return l
+}
+
+// ValidateWindow loads the window document at path for target and returns
+// its diagnostics. The error is reserved for load failures such as broken
+// imports; decode failures are reported as diagnostics.
+func (l *Service) ValidateWindow(ctx context.Context, path string, target *TargetContext, options ...ValidateOption) ([]Diagnostic, error) {
+	URL := l.getURL(path)
+	target, err := l.withFlags(ctx, target)
+	if err != nil {
+		return nil, err
+	}
+	session := newLoadSession(target)
+	node, err := l.resolveNode(ctx, URL, session)
+	if err != nil {
+		return nil, err
+	}
+	ApplyTarget(node, target)
+	if err := l.Localize(ctx, node, target); err != nil {
+		return nil, err
+	}
+	window := &types.Window{}
+	return newValidator(options...).validate(node, session.root(URL), session.origins, window), nil
+}
+
+// loadStrict resolves URL without the cache, validates it against v and
+// decodes it.
+func (l *Service) loadStrict(ctx context.Context, URL string, v interface{}, target *TargetContext) error {
+	session := newLoadSession(target)
+	node, err := l.resolveNode(ctx, URL, session)
+	if err != nil {
+		return err
+	}
+	ApplyTarget(node, target)
+	if err := l.Localize(ctx, node, target); err != nil {
+		return err
+	}
+	diagnostics := l.strict.validate(node, session.root(URL), session.origins, v)
+	if HasErrors(diagnostics) {
+		return &ValidationError{URL: URL, Diagnostics: diagnostics}
+	}
+	// Access is applied after validation so references into restricted
+	// nodes are still checked.
+	ApplyAccess(node, v, target.caller())
+	return decodeNode(URL, node, v)
+}
+
+// root returns the resolved URL of the document a session started from, so
+// diagnostics name root and imported files the same way.
+func (s *loadSession) root(URL string) string {
+	if len(s.sources) > 0 {
+		return s.sources[0]
+	}
+	return URL
+}
+
+// validate checks node against the Go type of v. Window documents also get
+// cross-reference checks; decode failures are reported as diagnostics.
+func (v *validator) validate(node *yaml.Node, URL string, origins map[*yaml.Node]string, target interface{}) []Diagnostic {
+	state := &validation{origins: origins}
+	if _, ok := target.(*types.Window); ok {
+		state.indexWindow(node, v.handlerNamespaces)
+	}
+	state.walk(node, reflect.TypeOf(target), "$", URL, false)
+	state.checkUnusedDataSources()
+	if err := node.Decode(target); err != nil {
+		line, column := yamlErrorPosition(err)
+		state.diagnostics = append(state.diagnostics, Diagnostic{Code: "decodeFailed", Severity: SeverityError, Message: err.Error(), SourcePath: URL, YAMLPath: "$", Line: line, Column: column})
+	}
+	return state.diagnostics
+}
+
+// validation holds the state of one validate call.
+type validation struct {
+	origins     map[*yaml.Node]string
+	window      bool
+	dataSources map[string]bool
+	dialogs     map[string]bool
+	namespaces  map[string]bool
+	declared    []declaredDataSource
+	referenced  map[string]bool
+	dynamicRefs bool
+	diagnostics []Diagnostic
+}
+
+// declaredDataSource locates a dataSource entry for unused reporting.
+type declaredDataSource struct {
+	name   string
+	node   *yaml.Node
+	path   string
+	source string
+}
+
+// indexWindow collects the ids cross references are resolved against.
+func (s *validation) indexWindow(node *yaml.Node, handlerNamespaces []string) {
+	var index struct {
+		Ns         []string             `yaml:"ns"`
+		Namespace  string               `yaml:"namespace"`
+		DataSource map[string]yaml.Node `yaml:"dataSource"`
+		Dialogs    []struct {
+			ID string `yaml:"id"`
+		} `yaml:"dialogs"`
+	}
+	_ = node.Decode(&index)
+	s.window = true
+	s.referenced = map[string]bool{}
+	s.dataSources = map[string]bool{}
+	for key := range index.DataSource {
+		s.dataSources[key] = true
+	}
+	s.dialogs = map[string]bool{}
+	for _, dialog := range index.Dialogs {
+		if dialog.ID != "" {
+			s.dialogs[dialog.ID] = true
+		}
+	}
+	s.namespaces = map[string]bool{}
+	for _, namespaces := range [][]string{builtinHandlerNamespaces, index.Ns, {index.Namespace}, handlerNamespaces} {
+		for _, namespace := range namespaces {
+			if namespace = strings.TrimSpace(namespace); namespace != "" {
+				s.namespaces[namespace] = true
+			}
+		}
+	}
+}
+
+// walk checks that every mapping key below node is a field of t. foreign is
+// set below nodes that address another window's data sources.
+func (s *validation) walk(node *yaml.Node, t reflect.Type, path, source string, foreign bool) {
+	if node == nil || t == nil {
+		return
+	}
+	if origin, ok := s.origins[node]; ok {
+		source = origin
+	}
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) > 0 {
+			s.walk(node.Content[0], t, path, source, foreign)
+		}
+		return
+	case yaml.AliasNode:
+		s.walk(node.Alias, t, path, source, foreign)
+		return
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		if node.Kind != yaml.MappingNode {
+			return
+		}
+		s.walkStruct(node, t, path, source, foreign)
+	case reflect.Map:
+		if node.Kind != yaml.MappingNode {
+			return
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			s.walk(node.Content[i+1], t.Elem(), path+"."+node.Content[i].Value, source, foreign)
+		}
+	case reflect.Slice, reflect.Array:
+		if node.Kind != yaml.SequenceNode {
+			return
+		}
+		if s.window && identifiedTypes[elemStruct(t)] {
+			s.checkDuplicateIDs(node, path, source)
+		}
+		for i, item := range node.Content {
+			s.walk(item, t.Elem(), fmt.Sprintf("%s[%d]", path, i), source, foreign)
+		}
+	}
+}
+
+func (s *validation) walkStruct(node *yaml.Node, t reflect.Type, path, source string, foreign bool) {
+	fields := fieldsOf(t)
+	switch t {
+	case lookupType:
+		if value := mappingValue(node, "windowId"); value != nil && strings.TrimSpace(value.Value) != "" {
+			foreign = true
+		}
+	case executeType:
+		if value := mappingValue(node, "handler"); value != nil && foreignParameterHandlers[strings.TrimSpace(value.Value)] {
+			foreign = true
+		}
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		keyNode, valueNode := node.Content[i], node.Content[i+1]
+		childPath := path + "." + keyNode.Value
+		// A patched value carries its own origin, and its key came with it.
+		childSource := source
+		if origin, ok := s.origins[valueNode]; ok {
+			childSource = origin
+		}
+		field, ok := fields.byName[keyNode.Value]
+		if !ok {
+			if !fields.open {
+				s.unknownField(keyNode, fields, childPath, childSource)
+			}
+			continue
+		}
+		if s.window {
+			s.checkReferences(field, valueNode, childPath, childSource, foreign)
+			if t == windowType && field.name == "DataSource" {
+				s.declareDataSources(valueNode, childPath, childSource)
+			}
+		}
+		if field.name == "TargetOverrides" && valueNode.Kind == yaml.MappingNode {
+			// Override bodies are merged into the owning node, so they share its fields.
+			for j := 0; j+1 < len(valueNode.Content); j += 2 {
+				s.walk(valueNode.Content[j+1], t, childPath+"."+valueNode.Content[j].Value, source, foreign)
+			}
+			continue
+		}
+		s.walk(valueNode, field.typ, childPath, source, foreign)
+	}
+}
+
+func (s *validation) unknownField(keyNode *yaml.Node, fields *yamlFields, path, source string) {
+	message := fmt.Sprintf("unknown field %q", keyNode.Value)
+	if suggestion := fields.closest(keyNode.Value); suggestion != "" {
+		message += fmt.Sprintf(" (did you mean %q?)", suggestion)
+	}
+	s.add("unknownField", SeverityError, message, source, path, keyNode)
+}
+
+// checkReferences resolves the window-level ids a field refers to.
+func (s *validation) checkReferences(field yamlField, node *yaml.Node, path, source string, foreign bool) {
+	switch {
+	case field.name == "DataSourceRef" || field.name == "DatasourceRef":
+		s.checkDataSource(node, path, source)
+	case field.owner == bindingType && field.name == "DataSourceRefs":
+		if node.Kind == yaml.MappingNode {
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				s.checkDataSource(node.Content[i+1], path+"."+node.Content[i].Value, source)
+			}
+		}
+	case field.owner == containerType && field.name == "Dialogs":
+		if node.Kind == yaml.SequenceNode {
+			for i, item := range node.Content {
+				s.checkDialog(item, fmt.Sprintf("%s[%d]", path, i), source)
+			}
+		}
+	case field.owner == lookupType && field.name == "DialogId":
+		s.checkDialog(node, path, source)
+	case field.owner == executeType && (field.name == "Handler" || field.name == "Init" || field.name == "OnError" || field.name == "OnDone" || field.name == "OnSuccess"),
+		field.owner == lookupFooterActionType && field.name == "Handler":
+		s.checkHandler(node, path, source)
+	case field.owner == parameterType && (field.name == "From" || field.name == "To"):
+		if !foreign {
+			s.checkParameterStore(node, path, source)
+		}
+	case field.owner == lookupType && field.name == "DataSource":
+		if !foreign {
+			s.reference(node)
+		}
+	case field.owner == itemType && field.name == "OptionDataSourceRets":
+		for _, item := range node.Content {
+			s.reference(item)
+		}
+	case field.owner == bindingType && field.name == "DataSourceRefSelector":
+		// The data source is picked at runtime, so any declared one may be used.
+		s.dynamicRefs = true
+	}
+}
+
+// reference marks a data source as used without requiring it to exist.
+func (s *validation) reference(node *yaml.Node) {
+	if node == nil || node.Kind != yaml.ScalarNode {
+		return
+	}
+	if value, ok := staticScalar(node); ok {
+		s.referenced[value] = true
+	} else if strings.TrimSpace(node.Value) != "" {
+		s.dynamicRefs = true
+	}
+}
+
+func (s *validation) declareDataSources(node *yaml.Node, path, source string) {
+	if node.Kind != yaml.MappingNode {
+		return
+	}
+	if origin, ok := s.origins[node]; ok {
+		source = origin
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		keyNode := node.Content[i]
+		s.declared = append(s.declared, declaredDataSource{name: keyNode.Value, node: keyNode, path: path + "." + keyNode.Value, source: source})
+	}
+}
+
+// checkUnusedDataSources reports data sources nothing in the document refers
+// to. Action code may still use them, so these are warnings.
+func (s *validation) checkUnusedDataSources() {
+	if !s.window || s.dynamicRefs {
+		return
+	}
+	for _, declared := range s.declared {
+		if !s.referenced[declared.name] {
+			s.add("dataSourceUnused", SeverityWarning, fmt.Sprintf("data source %q is never referenced", declared.name), declared.source, declared.path, declared.node)
+		}
+	}
+}
+
+// checkDuplicateIDs reports sibling entries sharing an id. Entries with a
+// target are skipped because alternatives for different targets may share one.
+func (s *validation) checkDuplicateIDs(node *yaml.Node, path, source string) {
+	seen := map[string]int{}
+	for i, item := range node.Content {
+		if item.Kind != yaml.MappingNode || mappingValue(item, "target") != nil {
+			continue
+		}
+		idNode := mappingValue(item, "id")
+		id, ok := staticScalar(idNode)
+		if !ok {
+			continue
+		}
+		if first, exists := seen[id]; exists {
+			itemSource := source
+			if origin, ok := s.origins[item]; ok {
+				itemSource = origin
+			}
+			s.add("duplicateId", SeverityError, fmt.Sprintf("id %q is already used by %s[%d]", id, path, first), itemSource, fmt.Sprintf("%s[%d].id", path, i), idNode)
+			continue
+		}
+		seen[id] = i
+	}
+}
+
+func (s *validation) checkDataSource(node *yaml.Node, path, source string) {
+	s.reference(node)
+	value, ok := staticScalar(node)
+	if !ok || s.dataSources[value] {
+		return
+	}
+	s.add("dataSourceNotFound", SeverityError, fmt.Sprintf("data source %q is not declared in dataSource", value), source, path, node)
+}
+
+func (s *validation) checkDialog(node *yaml.Node, path, source string) {
+	value, ok := staticScalar(node)
+	if !ok || s.dialogs[value] {
+		return
+	}
+	s.add("dialogNotFound", SeverityError, fmt.Sprintf("dialog %q is not declared in dialogs", value), source, path, node)
+}
+
+func (s *validation) checkHandler(node *yaml.Node, path, source string) {
+	value, ok := staticScalar(node)
+	if !ok {
+		return
+	}
+	index := strings.Index(value, ".")
+	if index <= 0 || s.namespaces[value[:index]] {
+		return
+	}
+	s.add("handlerNamespaceUnknown", SeverityWarning, fmt.Sprintf("handler %q uses unknown namespace %q", value, value[:index]), source, path, node)
+}
+
+// checkParameterStore validates the data source part of a "[dataSource]:store"
+// parameter address; blank and caller data sources are resolved at runtime.
+func (s *validation) checkParameterStore(node *yaml.Node, path, source string) {
+	value, ok := staticScalar(node)
+	if !ok {
+		return
+	}
+	index := strings.Index(value, ":")
+	if index <= 0 {
+		return
+	}
+	dataSource := value[:index]
+	s.referenced[dataSource] = true
+	if dataSource == "caller" || s.dataSources[dataSource] {
+		return
+	}
+	s.add("dataSourceNotFound", SeverityError, fmt.Sprintf("parameter %q references undeclared data source %q", value, dataSource), source, path, node)
+}
+
+func (s *validation) add(code, severity, message, source, path string, node *yaml.Node) {
+	diagnostic := Diagnostic{Code: code, Severity: severity, Message: message, SourcePath: source, YAMLPath: path}
+	if node != nil {
+		diagnostic.Line, diagnostic.Column = node.Line, node.Column
+	}
+	s.diagnostics = append(s.diagnostics, diagnostic)
+}
+
+// staticScalar returns a trimmed scalar value unless it is empty or templated.
+func staticScalar(node *yaml.Node) (string, bool) {
+	if node == nil || node.Kind != yaml.ScalarNode {
+		return "", false
+	}
+	value := strings.TrimSpace(node.Value)
+	if value == "" || strings.Contains(value, "${") || strings.Contains(value, "{{") {
+		return "", false
+	}
+	return value, true
+}
+
+var (
+	windowType             = reflect.TypeOf(types.Window{})
+	itemType               = reflect.TypeOf(types.Item{})
+	columnType             = reflect.TypeOf(types.Column{})
+	dialogType             = reflect.TypeOf(types.Dialog{})
+	bindingType            = reflect.TypeOf(types.Binding{})
+	containerType          = reflect.TypeOf(types.Container{})
+	lookupType             = reflect.TypeOf(types.Lookup{})
+	lookupFooterActionType = reflect.TypeOf(types.LookupFooterAction{})
+	executeType            = reflect.TypeOf(types.Execute{})
+	parameterType          = reflect.TypeOf(types.Parameter{})
+)
+
+// identifiedTypes are the list entries whose ids must be unique among siblings.
+var identifiedTypes = map[reflect.Type]bool{containerType: true, itemType: true, columnType: true, dialogType: true}
+
+func elemStruct(t reflect.Type) reflect.Type {
+	elem := t.Elem()
+	for elem.Kind() == reflect.Ptr {
+		elem = elem.Elem()
+	}
+	return elem
+}
+
+// yamlAliaser is implemented by types whose UnmarshalYAML accepts keys beyond
+// their own fields (see types.Container).
+type yamlAliaser interface {
+	YAMLAliases() interface{}
+}
+
+type yamlField struct {
+	owner reflect.Type
+	name  string
+	typ   reflect.Type
+}
+
+// yamlFields lists the keys a struct decodes; open is set when an inline map
+// accepts arbitrary keys.
+type yamlFields struct {
+	byName map[string]yamlField
+	open   bool
+}
+
+var fieldCache sync.Map
+
+// fieldsOf returns the YAML keys t decodes, following yaml.v3 naming rules.
+func fieldsOf(t reflect.Type) *yamlFields {
+	if cached, ok := fieldCache.Load(t); ok {
+		return cached.(*yamlFields)
+	}
+	result := &yamlFields{byName: map[string]yamlField{}}
+	collectFields(t, result)
+	if aliaser, ok := reflect.Zero(t).Interface().(yamlAliaser); ok {
+		aliases := &yamlFields{byName: map[string]yamlField{}}
+		collectFields(reflect.TypeOf(aliaser.YAMLAliases()), aliases)
+		for name, field := range aliases.byName {
+			if _, exists := result.byName[name]; !exists {
+				result.byName[name] = field
+			}
+		}
+	}
+	fieldCache.Store(t, result)
+	return result
+}
+
+func collectFields(t reflect.Type, result *yamlFields) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" && !field.Anonymous {
+			continue
+		}
+		tag := field.Tag.Get("yaml")
+		if tag == "-" {
+			continue
+		}
+		name, flags, _ := strings.Cut(tag, ",")
+		if strings.Contains(","+flags+",", ",inline,") {
+			fieldType := field.Type
+			for fieldType.Kind() == reflect.Ptr {
+				fieldType = fieldType.Elem()
+			}
+			switch fieldType.Kind() {
+			case reflect.Struct:
+				collectFields(fieldType, result)
+			case reflect.Map:
+				result.open = true
+			}
+			continue
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		result.byName[name] = yamlField{owner: t, name: field.Name, typ: field.Type}
+	}
+}
+
+// closest suggests a known key within a small edit distance of name.
+func (f *yamlFields) closest(name string) string {
+	names := make([]string, 0, len(f.byName))
+	for candidate := range f.byName {
+		names = append(names, candidate)
+	}
+	sort.Strings(names)
+	best, bestDistance := "", 3
+	for _, candidate := range names {
+		if distance := editDistance(strings.ToLower(name), strings.ToLower(candidate)); distance < bestDistance {
+			best, bestDistance = candidate, distance
+		}
+	}
+	return best
+}
+
+func editDistance(left, right string) int {
+	previous := make([]int, len(right)+1)
+	current := make([]int, len(right)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(left); i++ {
+		current[0] = i
+		for j := 1; j <= len(right); j++ {
+			cost := 1
+			if left[i-1] == right[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(right)]
+}
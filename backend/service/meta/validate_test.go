@@ -0,0 +1,191 @@
+package meta
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/viant/afs"
+	"github.com/viant/forge/backend/types"
+)
+
+const validWindowYAML = `namespace: order
+dataSource:
+  orders:
+    service:
+      endpoint: appAPI
+      uri: /orders
+  audit:
+    dataSourceRef: orders
+dialogs:
+  - id: pickCustomer
+    title: Pick customer
+    dataSourceRef: orders
+view:
+  content:
+    id: main
+    dataSourceRef: orders
+    dialogs: [pickCustomer]
+    targetOverrides:
+      mobile:
+        title: Orders
+    containers:
+      - id: kpis
+        kind: dashboard.summary
+        metrics:
+          - id: total
+        items:
+          - id: customer
+            lookup:
+              dialogId: pickCustomer
+              outputs:
+                - from: :output
+                  to: audit:form
+                  name: customerId
+            on:
+              - event: onClick
+                handler: window.openDialog
+                parameters:
+                  - from: orders:selection
+                    to: caller:form
+                    name: id
+`
+
+func TestValidateWindow_ValidWindowHasNoDiagnostics(t *testing.T) {
+	root := t.TempDir()
+	mustWriteMetaFile(t, filepath.Join(root, "window", "order", "main.yaml"), validWindowYAML)
+
+	service := New(afs.New(), filepath.Join(root, "window"))
+	diagnostics, err := service.ValidateWindow(context.Background(), "order/main.yaml", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(diagnostics) != 0 {
+		t.Fatalf("expected no diagnostics, got %v", diagnostics)
+	}
+}
+
+func TestValidateWindow_ReportsUnknownFieldsAndDanglingReferences(t *testing.T) {
+	root := t.TempDir()
+	base := filepath.Join(root, "window", "order")
+	mustWriteMetaFile(t, filepath.Join(base, "main.yaml"), `namespace: order
+dataSource:
+  orders: {}
+view:
+  content:
+    id: main
+    dataSourcRef: orders
+    dialogs: [missingDialog]
+    targetOverrides:
+      mobile:
+        titel: Orders
+    containers:
+      - $import(table.yaml)
+      - id: form
+        items:
+          - id: customer
+            lookup:
+              dialogId: pickCustomer
+            on:
+              - event: onChange
+                handler: billing.recalculate
+                parameters:
+                  - from: invoices:form
+                    to: :form
+                    name: total
+`)
+	mustWriteMetaFile(t, filepath.Join(base, "table.yaml"), "id: table\ndataSourceRef: products\ntable:\n  columns:\n    - id: name\n      widht: 10\n")
+
+	service := New(afs.New(), filepath.Join(root, "window"))
+	diagnostics, err := service.ValidateWindow(context.Background(), "order/main.yaml", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []struct {
+		code     string
+		yamlPath string
+		source   string
+		contains string
+	}{
+		{code: "unknownField", yamlPath: "$.view.content.dataSourcRef", source: "/order/main.yaml", contains: `did you mean "dataSourceRef"`},
+		{code: "dialogNotFound", yamlPath: "$.view.content.dialogs[0]", source: "/order/main.yaml"},
+		{code: "unknownField", yamlPath: "$.view.content.targetOverrides.mobile.titel", source: "/order/main.yaml"},
+		{code: "dataSourceNotFound", yamlPath: "$.view.content.containers[0].dataSourceRef", source: "/order/table.yaml"},
+		{code: "unknownField", yamlPath: "$.view.content.containers[0].table.columns[0].widht", source: "/order/table.yaml"},
+		{code: "dialogNotFound", yamlPath: "$.view.content.containers[1].items[0].lookup.dialogId", source: "/order/main.yaml"},
+		{code: "handlerNamespaceUnknown", yamlPath: "$.view.content.containers[1].items[0].on[0].handler", source: "/order/main.yaml"},
+		{code: "dataSourceNotFound", yamlPath: "$.view.content.containers[1].items[0].on[0].parameters[0].from", source: "/order/main.yaml"},
+		{code: "dataSourceUnused", yamlPath: "$.dataSource.orders", source: "/order/main.yaml"},
+	}
+	if len(diagnostics) != len(expected) {
+		t.Fatalf("expected %d diagnostics, got %d: %v", len(expected), len(diagnostics), diagnostics)
+	}
+	for i, want := range expected {
+		got := diagnostics[i]
+		if got.Code != want.code || got.YAMLPath != want.yamlPath || !strings.HasSuffix(got.SourcePath, want.source) || got.Line == 0 {
+			t.Fatalf("diagnostic %d: expected %s at %s in %s, got %#v", i, want.code, want.yamlPath, want.source, got)
+		}
+		if want.contains != "" && !strings.Contains(got.Message, want.contains) {
+			t.Fatalf("diagnostic %d: expected message containing %q, got %q", i, want.contains, got.Message)
+		}
+	}
+	for _, index := range []int{6, 8} {
+		if got := diagnostics[index].Severity; got != SeverityWarning {
+			t.Fatalf("diagnostic %d: expected warning, got %q", index, got)
+		}
+	}
+
+	diagnostics, err = service.ValidateWindow(context.Background(), "order/main.yaml", nil, WithHandlerNamespaces("billing"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, diagnostic := range diagnostics {
+		if diagnostic.Code == "handlerNamespaceUnknown" {
+			t.Fatalf("expected billing namespace to be accepted, got %v", diagnostic)
+		}
+	}
+}
+
+func TestLoadForTarget_StrictRejectsInvalidWindow(t *testing.T) {
+	root := t.TempDir()
+	mustWriteMetaFile(t, filepath.Join(root, "window", "order", "main.yaml"), "namespace: order\nview:\n  content:\n    id: main\n    dataSourcRef: orders\n")
+
+	lenient := New(afs.New(), filepath.Join(root, "window"))
+	if err := lenient.LoadForTarget(context.Background(), "order/main.yaml", &types.Window{}, nil); err != nil {
+		t.Fatalf("expected lenient load to succeed, got %v", err)
+	}
+	strict := New(afs.New(), filepath.Join(root, "window")).EnableStrict()
+	err := strict.LoadForTarget(context.Background(), "order/main.yaml", &types.Window{}, nil)
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("expected ValidationError, got %v", err)
+	}
+	if len(validationErr.Diagnostics) != 1 || validationErr.Diagnostics[0].Line != 5 || !strings.HasPrefix(validationErr.Diagnostics[0].SourcePath, "file://") {
+		t.Fatalf("unexpected diagnostics %v", validationErr.Diagnostics)
+	}
+}
+
+func TestValidateWindow_ReportsDuplicateSiblingIDs(t *testing.T) {
+	root := t.TempDir()
+	mustWriteMetaFile(t, filepath.Join(root, "window", "order", "main.yaml"), `view:
+  content:
+    containers:
+      - id: summary
+      - id: summary
+      - id: chart
+        target: web
+      - id: chart
+        target: android
+`)
+
+	service := New(afs.New(), filepath.Join(root, "window"))
+	diagnostics, err := service.ValidateWindow(context.Background(), "order/main.yaml", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(diagnostics) != 1 || diagnostics[0].Code != "duplicateId" || diagnostics[0].YAMLPath != "$.view.content.containers[1].id" {
+		t.Fatalf("expected one duplicate id diagnostic, got %v", diagnostics)
+	}
+}
@@ -0,0 +1,125 @@
+package meta
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/viant/afs/url"
+)
+
+// branchSegments are folder names treated as target branches.
+var branchSegments = map[string]bool{
+	"shared": true, "web": true, "android": true, "ios": true, "mobile": true,
+	"phone": true, "tablet": true, "foldable": true, "desktop": true,
+}
+
+// WindowKeys lists the window keys below dir, merged across the overlay
+// chain: every folder holding a main.yaml, with branch folders such as
+// shared, web or android/phone stripped, and every top-level YAML file.
+func (l *Service) WindowKeys(ctx context.Context, dir string) ([]string, error) {
+	seen := map[string]bool{}
+	var topLevel []string
+	var visit func(rel string) error
+	visit = func(rel string) error {
+		location := l.getURL(path.Join(dir, rel))
+		objects, err := l.listOverlay(ctx, location)
+		if err != nil {
+			return fmt.Errorf("list %s: %w", location, err)
+		}
+		for _, object := range objects {
+			if url.Equals(location, object.URL()) || strings.HasPrefix(object.Name(), ".") {
+				continue
+			}
+			childRel := path.Join(rel, object.Name())
+			switch {
+			case object.IsDir():
+				if err := visit(childRel); err != nil {
+					return err
+				}
+			case object.Name() == "main.yaml":
+				if key := WindowKey(rel); key != "" {
+					seen[key] = true
+				}
+			case rel == "" && strings.HasSuffix(object.Name(), ".yaml"):
+				topLevel = append(topLevel, strings.TrimSuffix(object.Name(), ".yaml"))
+			}
+		}
+		return nil
+	}
+	if err := visit(""); err != nil {
+		return nil, err
+	}
+	for _, key := range topLevel {
+		seen[key] = true
+	}
+	keys := make([]string, 0, len(seen))
+	for key := range seen {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// ResolveWindowKey returns the base path (see ResolveWindowBase) of window
+// key for target: the folder form key/main, else the single-file form key.
+func (l *Service) ResolveWindowKey(ctx context.Context, key string, target *TargetContext) (string, error) {
+	base, err := l.ResolveWindowBase(ctx, url.Join(key, "main"), target)
+	if err != nil {
+		base, err = l.ResolveWindowBase(ctx, key, target)
+	}
+	return base, err
+}
+
+// WindowKey strips trailing branch folders such as shared, web or
+// android/phone from the folder holding a main.yaml.
+func WindowKey(dir string) string {
+	segments := strings.Split(dir, "/")
+	for len(segments) > 0 && isBranchSegment(segments[len(segments)-1]) {
+		segments = segments[:len(segments)-1]
+	}
+	return strings.Join(segments, "/")
+}
+
+func isBranchSegment(segment string) bool {
+	for _, part := range strings.Split(segment, ".") {
+		if !branchSegments[part] {
+			return false
+		}
+	}
+	return segment != ""
+}
+
+// String returns "platform[/formFactor[/surface]]".
+func (t *TargetContext) String() string {
+	if t == nil {
+		return ""
+	}
+	parts := []string{t.Platform}
+	if t.FormFactor != "" || t.Surface != "" {
+		parts = append(parts, t.FormFactor)
+	}
+	if t.Surface != "" {
+		parts = append(parts, t.Surface)
+	}
+	return strings.Join(parts, "/")
+}
+
+// ParseTarget parses "platform[/formFactor[/surface]]", the form String
+// returns.
+func ParseTarget(value string) (*TargetContext, error) {
+	parts := strings.Split(strings.TrimSpace(value), "/")
+	if len(parts) > 3 || strings.TrimSpace(parts[0]) == "" {
+		return nil, fmt.Errorf("invalid target %q: expected platform[/formFactor[/surface]]", value)
+	}
+	result := &TargetContext{Platform: strings.TrimSpace(parts[0])}
+	if len(parts) > 1 {
+		result.FormFactor = strings.TrimSpace(parts[1])
+	}
+	if len(parts) > 2 {
+		result.Surface = strings.TrimSpace(parts[2])
+	}
+	return result, nil
+}
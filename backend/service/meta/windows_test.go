@@ -0,0 +1,28 @@
+package meta
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/viant/afs"
+)
+
+func TestWindowKeys_MergesOverlayRoots(t *testing.T) {
+	tenant, defaults := t.TempDir(), t.TempDir()
+	mustWriteMetaFile(t, filepath.Join(defaults, "order", "shared", "main.yaml"), "namespace: order\n")
+	mustWriteMetaFile(t, filepath.Join(defaults, "billing", "invoice", "web", "main.yaml"), "namespace: invoice\n")
+	mustWriteMetaFile(t, filepath.Join(defaults, "campaign.yaml"), "namespace: campaign\n")
+	mustWriteMetaFile(t, filepath.Join(tenant, "order", "web", "main.yaml"), "namespace: order\n")
+	mustWriteMetaFile(t, filepath.Join(tenant, "customer", "android", "phone", "main.yaml"), "namespace: customer\n")
+
+	keys, err := NewWithRoots(afs.New(), []string{tenant, defaults}).WindowKeys(context.Background(), "")
+	if err != nil {
+		t.Fatalf("WindowKeys() error = %v", err)
+	}
+	expected := []string{"billing/invoice", "campaign", "customer", "order"}
+	if !reflect.DeepEqual(keys, expected) {
+		t.Fatalf("expected %v, got %v", expected, keys)
+	}
+}
@@ -0,0 +1,278 @@
+// Package schema generates JSON Schema documents for Forge metadata types, so
+// YAML editors such as VS Code's YAML language server can validate and
+// autocomplete window and navigation files.
+package schema
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/viant/forge/backend/types"
+)
+
+// Draft is the JSON Schema dialect of generated documents.
+const Draft = "http://json-schema.org/draft-07/schema#"
+
+const (
+	importDefinition = "importDirective"
+	definitionsRef   = "#/definitions/"
+)
+
+// Schema is a JSON Schema node.
+type Schema struct {
+	Schema               string             `json:"$schema,omitempty"`
+	ID                   string             `json:"$id,omitempty"`
+	Ref                  string             `json:"$ref,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties interface{}        `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AnyOf                []*Schema          `json:"anyOf,omitempty"`
+	Definitions          map[string]*Schema `json:"definitions,omitempty"`
+}
+
+// Option configures a Generator.
+type Option func(*Generator)
+
+// WithID sets the $id of generated documents.
+func WithID(id string) Option {
+	return func(g *Generator) {
+		g.id = id
+	}
+}
+
+// WithShortForm declares additional forms the type of v accepts through a
+// custom unmarshaler, e.g. a plain string for a struct.
+func WithShortForm(v interface{}, alternatives ...*Schema) Option {
+	return func(g *Generator) {
+		t := indirect(reflect.TypeOf(v))
+		g.shortForms[t] = append(g.shortForms[t], alternatives...)
+	}
+}
+
+// WithoutImports omits the $import directive alternative. By default every
+// object or list position also accepts an $import(...) string and objects
+// accept $extend/$patch, matching what meta.Service resolves.
+func WithoutImports() Option {
+	return func(g *Generator) {
+		g.imports = false
+	}
+}
+
+// Generator reflects Go types into JSON Schema following yaml.v3 field
+// naming: yaml tags, ",inline" structs and maps, and the extra keys types
+// declare through YAMLAliases (see types.Container).
+type Generator struct {
+	id          string
+	imports     bool
+	shortForms  map[reflect.Type][]*Schema
+	definitions map[string]*Schema
+	names       map[reflect.Type]string
+}
+
+// yamlAliaser is implemented by types whose UnmarshalYAML accepts keys beyond
+// their own fields.
+type yamlAliaser interface {
+	YAMLAliases() interface{}
+}
+
+func New(options ...Option) *Generator {
+	stringList := &Schema{Type: "array", Items: &Schema{Type: "string"}}
+	result := &Generator{
+		imports: true,
+		shortForms: map[reflect.Type][]*Schema{
+			// TargetSpec and AccessSpec take a name or a list of names.
+			reflect.TypeOf(types.TargetSpec{}): {{Type: "string"}, stringList},
+			reflect.TypeOf(types.AccessSpec{}): {{Type: "string"}, stringList},
+		},
+	}
+	for _, option := range options {
+		if option != nil {
+			option(result)
+		}
+	}
+	return result
+}
+
+// Generate returns the schema document for the type of v.
+func (g *Generator) Generate(v interface{}, title string) (*Schema, error) {
+	if v == nil {
+		return nil, fmt.Errorf("schema root is nil")
+	}
+	g.definitions = map[string]*Schema{}
+	g.names = map[reflect.Type]string{}
+	root := g.schemaOf(reflect.TypeOf(v))
+	if g.imports {
+		g.definitions[importDefinition] = &Schema{
+			Type:        "string",
+			Pattern:     `^\s*\$import\(.+\)\s*$`,
+			Description: "$import(path[:key][, name=value...]) replaces the node with imported content",
+		}
+	}
+	return &Schema{
+		Schema:      Draft,
+		ID:          g.id,
+		Title:       title,
+		Ref:         root.Ref,
+		Type:        root.Type,
+		Items:       root.Items,
+		AnyOf:       root.AnyOf,
+		Definitions: g.definitions,
+	}, nil
+}
+
+// Window generates the schema of a window document.
+func Window(options ...Option) (*Schema, error) {
+	return New(options...).Generate(&types.Window{}, "Forge window")
+}
+
+// Navigation generates the schema of a navigation document.
+func Navigation(options ...Option) (*Schema, error) {
+	return New(options...).Generate([]types.NavigationItem{}, "Forge navigation")
+}
+
+func (g *Generator) schemaOf(t reflect.Type) *Schema {
+	t = indirect(t)
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return g.withImport(&Schema{Type: "array", Items: g.schemaOf(t.Elem())})
+	case reflect.Map:
+		return g.withImport(&Schema{Type: "object", AdditionalProperties: g.schemaOf(t.Elem())})
+	case reflect.Struct:
+		return &Schema{Ref: definitionsRef + g.define(t)}
+	}
+	// interface{} and anything else accept every value.
+	return &Schema{}
+}
+
+// define adds the definition of struct t and returns its name.
+func (g *Generator) define(t reflect.Type) string {
+	if name, ok := g.names[t]; ok {
+		return name
+	}
+	name := g.definitionName(t)
+	g.names[t] = name
+	object := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	g.definitions[name] = object
+	if open := g.collect(t, object.Properties); !open {
+		object.AdditionalProperties = false
+	}
+	if aliaser, ok := reflect.Zero(t).Interface().(yamlAliaser); ok {
+		aliases := map[string]*Schema{}
+		g.collect(reflect.TypeOf(aliaser.YAMLAliases()), aliases)
+		for key, alias := range aliases {
+			if existing, ok := object.Properties[key]; ok {
+				object.Properties[key] = &Schema{AnyOf: []*Schema{existing, alias}}
+				continue
+			}
+			object.Properties[key] = alias
+		}
+	}
+	if g.imports {
+		object.Properties["$extend"] = &Schema{Type: "string", Description: "base node to merge this mapping over, as an $import directive or path"}
+		object.Properties["$patch"] = &Schema{Type: "object", Description: "keys merged over the $extend base"}
+	}
+	alternatives := g.shortForms[t]
+	if len(alternatives) == 0 && !g.imports {
+		return name
+	}
+	g.definitions[name] = &Schema{AnyOf: append(append([]*Schema{object}, alternatives...), g.importRef()...)}
+	return name
+}
+
+// collect adds the YAML keys of struct t to properties and reports whether an
+// inline map accepts arbitrary keys.
+func (g *Generator) collect(t reflect.Type, properties map[string]*Schema) bool {
+	open := false
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" && !field.Anonymous {
+			continue
+		}
+		tag := field.Tag.Get("yaml")
+		if tag == "-" {
+			continue
+		}
+		name, flags, _ := strings.Cut(tag, ",")
+		if strings.Contains(","+flags+",", ",inline,") {
+			switch fieldType := indirect(field.Type); fieldType.Kind() {
+			case reflect.Struct:
+				open = g.collect(fieldType, properties) || open
+			case reflect.Map:
+				open = true
+			}
+			continue
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		properties[name] = g.schemaOf(field.Type)
+	}
+	return open
+}
+
+// definitionName is the type name, qualified by package on collisions.
+func (g *Generator) definitionName(t reflect.Type) string {
+	name := t.Name()
+	if name == "" {
+		name = "Anonymous"
+	}
+	taken := func(candidate string) bool {
+		for other, used := range g.names {
+			if used == candidate && other != t {
+				return true
+			}
+		}
+		return false
+	}
+	if !taken(name) {
+		return name
+	}
+	pkg := t.PkgPath()
+	if index := strings.LastIndex(pkg, "/"); index != -1 {
+		pkg = pkg[index+1:]
+	}
+	candidate := pkg + "." + name
+	for i := 2; taken(candidate); i++ {
+		candidate = fmt.Sprintf("%s.%s%d", pkg, name, i)
+	}
+	return candidate
+}
+
+// withImport lets an array or map position hold an $import directive.
+func (g *Generator) withImport(schema *Schema) *Schema {
+	if !g.imports {
+		return schema
+	}
+	return &Schema{AnyOf: append([]*Schema{schema}, g.importRef()...)}
+}
+
+func (g *Generator) importRef() []*Schema {
+	if !g.imports {
+		return nil
+	}
+	return []*Schema{{Ref: definitionsRef + importDefinition}}
+}
+
+func indirect(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
@@ -0,0 +1,80 @@
+package schema
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestWindow_ReflectsModel(t *testing.T) {
+	document, err := Window()
+	if err != nil {
+		t.Fatalf("Window() error = %v", err)
+	}
+	if document.Ref != "#/definitions/Window" || document.Schema != Draft {
+		t.Fatalf("unexpected root %+v", document)
+	}
+	if _, err := json.Marshal(document); err != nil {
+		t.Fatalf("marshal schema: %v", err)
+	}
+
+	container := object(t, document, "Container")
+	for _, key := range []string{"id", "dataSourceRef", "requiresCapabilities", "fallback", "metrics", "report", "$extend"} {
+		if container.Properties[key] == nil {
+			t.Fatalf("expected Container property %q", key)
+		}
+	}
+	if container.AdditionalProperties != false {
+		t.Fatalf("expected closed Container object, got %v", container.AdditionalProperties)
+	}
+	// Container.items and the dashboard filter items alias share one key.
+	if items := container.Properties["items"]; len(items.AnyOf) != 2 {
+		t.Fatalf("expected items to accept both forms, got %+v", items)
+	}
+
+	target := document.Definitions["TargetSpec"]
+	if len(target.AnyOf) != 4 || target.AnyOf[1].Type != "string" || target.AnyOf[2].Type != "array" || target.AnyOf[3].Ref != "#/definitions/importDirective" {
+		t.Fatalf("expected TargetSpec short forms, got %+v", target.AnyOf)
+	}
+	if object(t, document, "TargetSpec").Properties["platforms"] == nil {
+		t.Fatalf("expected TargetSpec object form")
+	}
+	if document.Definitions["AccessSpec"] == nil || len(document.Definitions["AccessSpec"].AnyOf) != 4 {
+		t.Fatalf("expected AccessSpec short forms")
+	}
+}
+
+func TestGenerator_WithoutImports(t *testing.T) {
+	document, err := Navigation(WithoutImports(), WithID("https://example.com/navigation.json"))
+	if err != nil {
+		t.Fatalf("Navigation() error = %v", err)
+	}
+	if document.Type != "array" || document.Items.Ref != "#/definitions/NavigationItem" || document.ID != "https://example.com/navigation.json" {
+		t.Fatalf("unexpected navigation root %+v", document)
+	}
+	if _, ok := document.Definitions[importDefinition]; ok {
+		t.Fatalf("expected no import definition")
+	}
+	item := document.Definitions["NavigationItem"]
+	if item.Type != "object" || item.Properties["$extend"] != nil || item.Properties["childNodes"] == nil {
+		t.Fatalf("unexpected NavigationItem %+v", item)
+	}
+}
+
+// object returns the object form of a definition.
+func object(t *testing.T, document *Schema, name string) *Schema {
+	t.Helper()
+	definition := document.Definitions[name]
+	if definition == nil {
+		t.Fatalf("expected definition %s", name)
+	}
+	if definition.Type == "object" {
+		return definition
+	}
+	for _, alternative := range definition.AnyOf {
+		if alternative.Type == "object" {
+			return alternative
+		}
+	}
+	t.Fatalf("expected object form of %s", name)
+	return nil
+}
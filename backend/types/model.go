@@ -18,6 +18,12 @@ type TargetSpec struct {
 	ExcludePlatforms []string `json:"excludePlatforms,omitempty" yaml:"excludePlatforms,omitempty"`
 	FormFactors      []string `json:"formFactors,omitempty" yaml:"formFactors,omitempty"`
 	Capabilities     []string `json:"capabilities,omitempty" yaml:"capabilities,omitempty"`
+	// Flags are feature-flag expressions that must all hold, e.g. "newOrders"
+	// or "beta && !legacyGrid"; flags are resolved server-side.
+	Flags []string `json:"flags,omitempty" yaml:"flags,omitempty"`
+	// AppVersion is a client version range such as ">=2.4 <3"; targets
+	// without an app version do not match it.
+	AppVersion string `json:"appVersion,omitempty" yaml:"appVersion,omitempty"`
 }
 
 func (t *TargetSpec) UnmarshalJSON(data []byte) error {
@@ -73,6 +79,8 @@ func (t *TargetSpec) normalize() {
 	t.ExcludePlatforms = targetStringList(t.ExcludePlatforms)
 	t.FormFactors = targetStringList(t.FormFactors)
 	t.Capabilities = targetStringList(t.Capabilities)
+	t.Flags = targetStringList(t.Flags)
+	t.AppVersion = strings.TrimSpace(t.AppVersion)
 }
 
 func targetStringList(values []string) []string {
@@ -88,6 +96,135 @@ func targetStringList(values []string) []string {
 	return result
 }
 
+// AccessSpec restricts a metadata node to callers. It is evaluated and
+// removed server-side, so restricted nodes never reach the client.
+// visibleFor matches a caller with any listed role, scope or claim;
+// requires matches a caller with all of them. Short forms list roles:
+//
+//	visibleFor: admin
+//	visibleFor: [admin, manager]
+//	requires: {roles: [finance], scopes: [orders.write], claims: {tenant: acme}}
+//
+// Claim values may be a string or a list of accepted values; a claim listed
+// by name only, e.g. claims: tenant, must be present with any value.
+type AccessSpec struct {
+	Roles  []string               `json:"roles,omitempty" yaml:"roles,omitempty"`
+	Scopes []string               `json:"scopes,omitempty" yaml:"scopes,omitempty"`
+	Claims map[string]interface{} `json:"claims,omitempty" yaml:"claims,omitempty"`
+}
+
+func (a *AccessSpec) UnmarshalJSON(data []byte) error {
+	if len(data) == 0 || string(data) == "null" {
+		return nil
+	}
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		a.Roles = targetStringList([]string{text})
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err == nil {
+		a.Roles = targetStringList(list)
+		return nil
+	}
+	var expanded map[string]interface{}
+	if err := json.Unmarshal(data, &expanded); err != nil {
+		return fmt.Errorf("invalid access spec: %w", err)
+	}
+	return a.expand(expanded)
+}
+
+func (a *AccessSpec) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var text string
+	if err := unmarshal(&text); err == nil {
+		a.Roles = targetStringList([]string{text})
+		return nil
+	}
+	var list []string
+	if err := unmarshal(&list); err == nil {
+		a.Roles = targetStringList(list)
+		return nil
+	}
+	var expanded map[string]interface{}
+	if err := unmarshal(&expanded); err != nil {
+		return fmt.Errorf("invalid access spec: %w", err)
+	}
+	return a.expand(expanded)
+}
+
+// expand decodes the mapping form. roles and scopes take a name or a list;
+// claims takes a mapping of accepted values, or claim names that only need
+// to be present. Unknown keys are rejected so a misspelled rule never
+// silently restricts nothing.
+func (a *AccessSpec) expand(expanded map[string]interface{}) error {
+	for key, value := range expanded {
+		var err error
+		switch key {
+		case "roles":
+			a.Roles, err = accessStrings(value)
+		case "scopes":
+			a.Scopes, err = accessStrings(value)
+		case "claims":
+			a.Claims, err = accessClaims(value)
+		default:
+			err = fmt.Errorf("unknown key %q", key)
+		}
+		if err != nil {
+			return fmt.Errorf("invalid access spec: %w", err)
+		}
+	}
+	a.normalize()
+	return nil
+}
+
+func accessStrings(value interface{}) ([]string, error) {
+	switch actual := value.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []string{actual}, nil
+	case []interface{}:
+		result := make([]string, 0, len(actual))
+		for _, item := range actual {
+			switch item.(type) {
+			case map[string]interface{}, []interface{}, nil:
+				return nil, fmt.Errorf("expected names, got %v", value)
+			}
+			result = append(result, fmt.Sprint(item))
+		}
+		return result, nil
+	}
+	return nil, fmt.Errorf("expected a name or a list of names, got %v", value)
+}
+
+func accessClaims(value interface{}) (map[string]interface{}, error) {
+	if claims, ok := value.(map[string]interface{}); ok {
+		return claims, nil
+	}
+	names, err := accessStrings(value)
+	if err != nil {
+		return nil, fmt.Errorf("expected claims mapping or claim names, got %v", value)
+	}
+	result := map[string]interface{}{}
+	for _, name := range targetStringList(names) {
+		result[name] = nil
+	}
+	return result, nil
+}
+
+// IsEmpty reports whether the spec restricts nothing.
+func (a *AccessSpec) IsEmpty() bool {
+	return a == nil || (len(a.Roles) == 0 && len(a.Scopes) == 0 && len(a.Claims) == 0)
+}
+
+func (a *AccessSpec) normalize() {
+	if a == nil {
+		return
+	}
+	a.Roles = targetStringList(a.Roles)
+	a.Scopes = targetStringList(a.Scopes)
+}
+
 type Chart struct {
 	Type                  string            `json:"type" yaml:"type"`
 	DataSourceRef         string            `json:"dataSourceRef,omitempty" yaml:"dataSourceRef,omitempty"`
@@ -164,6 +301,8 @@ type NavigationItem struct {
 	ChildNodes      []NavigationItem                  `json:"childNodes,omitempty" yaml:"childNodes,omitempty"`
 	Target          *TargetSpec                       `json:"target,omitempty" yaml:"target,omitempty"`
 	TargetOverrides map[string]map[string]interface{} `json:"targetOverrides,omitempty" yaml:"targetOverrides,omitempty"`
+	VisibleFor      *AccessSpec                       `json:"visibleFor,omitempty" yaml:"visibleFor,omitempty"`
+	Requires        *AccessSpec                       `json:"requires,omitempty" yaml:"requires,omitempty"`
 }
 
 // Dialog represents a dialog with a title, content, actions, and on events.
@@ -444,6 +583,8 @@ type Container struct {
 	Binding           `yaml:",inline"`
 	Target            *TargetSpec                       `json:"target,omitempty" yaml:"target,omitempty"`
 	TargetOverrides   map[string]map[string]interface{} `json:"targetOverrides,omitempty" yaml:"targetOverrides,omitempty"`
+	VisibleFor        *AccessSpec                       `json:"visibleFor,omitempty" yaml:"visibleFor,omitempty"`
+	Requires          *AccessSpec                       `json:"requires,omitempty" yaml:"requires,omitempty"`
 	State             *Parameter                        `json:"state,omitempty" yaml:"state,omitempty"`
 	Title             string                            `json:"title,omitempty" yaml:"title,omitempty"`
 	Subtitle          string                            `json:"subtitle,omitempty" yaml:"subtitle,omitempty"`
@@ -478,6 +619,12 @@ type Container struct {
 	SelectFirst       bool                              `json:"selectFirst,omitempty"  yaml:"selectFirst,omitempty"`
 	FetchData         bool                              `json:"fetchData,omitempty"  yaml:"fetchData,omitempty"`
 	Dashboard         *Dashboard                        `json:"dashboard,omitempty" yaml:"dashboard,omitempty"`
+
+	// RequiresCapabilities lists client capabilities the container needs;
+	// for targets missing one the backend renders Fallback instead, or
+	// drops the container when there is none.
+	RequiresCapabilities []string   `json:"requiresCapabilities,omitempty" yaml:"requiresCapabilities,omitempty"`
+	Fallback             *Container `json:"fallback,omitempty" yaml:"fallback,omitempty"`
 }
 
 func (c *Container) UnmarshalJSON(data []byte) error {
@@ -903,6 +1050,13 @@ type dashboardCompactAliases struct {
 	RowActions       []DashboardTableAction            `json:"rowActions,omitempty" yaml:"rowActions,omitempty"`
 }
 
+// YAMLAliases returns the compact dashboard aliases Container also accepts at
+// the container level, so tooling that reflects over raw YAML keys sees every
+// key UnmarshalYAML honours.
+func (Container) YAMLAliases() interface{} {
+	return dashboardCompactAliases{}
+}
+
 func isDashboardContainerKind(kind string) bool {
 	return strings.HasPrefix(strings.TrimSpace(kind), "dashboard.")
 }
@@ -1247,6 +1401,8 @@ type Column struct {
 	Progress          *Progress              `json:"progress,omitempty" yaml:"progress,omitempty"`
 	On                []*Execute             `json:"on,omitempty" yaml:"on,omitempty"`
 	ToolTip           string                 `json:"tooltip" yaml:"tooltip"`
+	VisibleFor        *AccessSpec            `json:"visibleFor,omitempty" yaml:"visibleFor,omitempty"`
+	Requires          *AccessSpec            `json:"requires,omitempty" yaml:"requires,omitempty"`
 }
 
 // TemplateItem represents a single template item with an ID and an operator.
@@ -1289,6 +1445,8 @@ type Item struct {
 	Binding              `yaml:",inline"`
 	Target               *TargetSpec                       `json:"target,omitempty" yaml:"target,omitempty"`
 	TargetOverrides      map[string]map[string]interface{} `json:"targetOverrides,omitempty" yaml:"targetOverrides,omitempty"`
+	VisibleFor           *AccessSpec                       `json:"visibleFor,omitempty" yaml:"visibleFor,omitempty"`
+	Requires             *AccessSpec                       `json:"requires,omitempty" yaml:"requires,omitempty"`
 	OptionDataSourceRets []string                          `json:"optionDataSourceRets,omitempty" yaml:"optionDataSourceRets,omitempty"`
 	Value                interface{}                       `json:"value,omitempty" yaml:"value,omitempty"`
 	Style                *StyleProperties                  `json:"style,omitempty" yaml:"style,omitempty"`
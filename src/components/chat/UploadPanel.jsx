@@ -28,7 +28,7 @@ export default function UploadPanel({ uploads = [], onAbort, onClose }) {
               </div>
               <ProgressBar animate stripes value={u.progress || 0} intent={u.status === UploadStatus.ERROR ? 'danger' : undefined} />
               <div className="mt-1 text-xs text-gray-500">
-                {u.status === UploadStatus.ERROR && (<span className="text-red-600">Upload failed</span>)}
+                {u.status === UploadStatus.ERROR && (<span className="text-red-600">{u.error?.message || 'Upload failed'}</span>)}
                 {u.status === UploadStatus.ABORTED && (<span>Aborted</span>)}
                 {u.status === UploadStatus.DONE && (<span>Completed</span>)}
               </div>
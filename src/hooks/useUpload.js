@@ -21,6 +21,19 @@ function resolveUploadUrl(uploadConfig = {}, endpoints = {}) {
   return '/upload';
 }
 
+// Build an Error from a failed upload response. Policy rejections come back
+// as problem documents (or wrapped in {status: 'error', error}) carrying a
+// displayable detail and a code such as 'upload_too_large'.
+function uploadError(xhr) {
+  let body = null;
+  try { body = JSON.parse(xhr.responseText || 'null'); } catch (_) {}
+  const problem = body?.error || body || {};
+  const error = new Error(problem.detail || problem.title || xhr.statusText || 'Upload failed');
+  error.status = xhr.status;
+  if (problem.code) error.code = problem.code;
+  return error;
+}
+
 export default function useUpload(uploadConfig = {}) {
   const { endpoints } = useSetting();
   const url = resolveUploadUrl(uploadConfig, endpoints);
@@ -77,7 +90,7 @@ export default function useUpload(uploadConfig = {}) {
           try { resp = JSON.parse(xhr.responseText || 'null'); } catch (_) {}
           setItems(prev => prev.map(x => x.id === item.id ? { ...x, progress: 1, status: DONE, response: resp } : x));
         } else {
-          setItems(prev => prev.map(x => x.id === item.id ? { ...x, status: ERROR, error: new Error(xhr.statusText || 'Upload failed') } : x));
+          setItems(prev => prev.map(x => x.id === item.id ? { ...x, status: ERROR, error: uploadError(xhr) } : x));
         }
         delete xhrsRef.current[item.id];
       };